@@ -0,0 +1,200 @@
+//Command cinder-log filters and tails log files written by logger.Log
+//
+//	cinder-log [flags] file...
+//
+//Rotated and gzipped files of each log are read first, oldest to newest, unless -rotated=false.
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/random9s/cinder/logger"
+	"github.com/random9s/cinder/logger/reader"
+)
+
+//options are set from the command line flags
+type options struct {
+	level, since, until, caller, grep, output string
+	follow, rotated                           bool
+}
+
+func main() {
+	var opts options
+	flag.StringVar(&opts.level, "level", "", "minimum `level` to show, such as warn")
+	flag.StringVar(&opts.since, "since", "", "show entries after this RFC3339 `time`, or this long ago such as 1h")
+	flag.StringVar(&opts.until, "until", "", "show entries before this RFC3339 `time`, or this long ago such as 10m")
+	flag.StringVar(&opts.caller, "caller", "", "show entries logged from this `file` or file:line")
+	flag.StringVar(&opts.grep, "grep", "", "show entries matching this `regexp`")
+	flag.StringVar(&opts.output, "o", "text", "output `format`, text or json")
+	flag.BoolVar(&opts.follow, "f", false, "keep printing entries as they're appended to the file")
+	flag.BoolVar(&opts.rotated, "rotated", true, "read rotated files before each file")
+
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: cinder-log [flags] file...\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if err := run(flag.Args(), opts); err != nil {
+		fmt.Fprintf(os.Stderr, "cinder-log: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(paths []string, opts options) error {
+	if len(paths) == 0 {
+		flag.Usage()
+		return errors.New("no log files given")
+	}
+
+	if opts.follow && len(paths) > 1 {
+		return errors.New("only one file can be followed")
+	}
+
+	var level = parseLevel(opts.level)
+	if _, ok := logger.Severity(level); level != "" && !ok {
+		return fmt.Errorf("unknown log level %q", opts.level)
+	}
+
+	var filter = reader.Filter{
+		Level:  level,
+		Caller: opts.caller,
+	}
+
+	var err error
+	if filter.Since, err = parseTime(opts.since); err != nil {
+		return err
+	}
+
+	if filter.Until, err = parseTime(opts.until); err != nil {
+		return err
+	}
+
+	if opts.grep != "" {
+		if filter.Pattern, err = regexp.Compile(opts.grep); err != nil {
+			return err
+		}
+	}
+
+	var enc logger.Encoder
+	switch opts.output {
+	case "text":
+		enc = logger.TextEncoder
+	case "json":
+		enc = logger.JSONEncoder
+	default:
+		return fmt.Errorf("unknown output format %q", opts.output)
+	}
+
+	var w = bufio.NewWriter(os.Stdout)
+	defer w.Flush()
+
+	for _, path := range paths {
+		var files = []string{path}
+		if opts.rotated {
+			if files, err = reader.Files(path); err != nil {
+				return err
+			}
+
+			if len(files) == 0 {
+				return fmt.Errorf("no log files found at %s", path)
+			}
+		}
+
+		for _, f := range files {
+			if err := readFile(w, f, filter, enc); err != nil {
+				return err
+			}
+		}
+	}
+
+	if !opts.follow {
+		return nil
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	err = reader.Follow(ctx, paths[0], filter, func(e *logger.Entry, _ string) error {
+		if _, err := w.Write(enc(e)); err != nil {
+			return err
+		}
+		return w.Flush()
+	})
+
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
+
+func readFile(w io.Writer, path string, filter reader.Filter, enc logger.Encoder) error {
+	f, err := reader.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var r = reader.New(f, filter)
+	for {
+		e, err := r.Next()
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+
+		if _, err := w.Write(enc(e)); err != nil {
+			return err
+		}
+	}
+}
+
+//levelAliases are other common spellings of the built in levels
+var levelAliases = map[string]string{
+	"DEBUG": logger.TRACE,
+	"WARN":  logger.WARN,
+	"ERR":   logger.ERR,
+}
+
+//parseLevel returns the level named by s in any case, accepting the common aliases
+func parseLevel(s string) string {
+	var level = strings.ToUpper(s)
+	if alias, ok := levelAliases[level]; ok {
+		return alias
+	}
+	return level
+}
+
+//parseTime parses an RFC3339 time, or a duration before now
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(-d), nil
+	}
+
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q, expected RFC3339 or a duration", s)
+	}
+	return t, nil
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/random9s/cinder/logger"
+)
+
+func TestParseLevel(t *testing.T) {
+	var levels = map[string]string{
+		"":        "",
+		"warn":    logger.WARN,
+		"Warning": logger.WARN,
+		"err":     logger.ERR,
+		"debug":   logger.TRACE,
+		"info":    logger.INFO,
+	}
+
+	for s, expected := range levels {
+		if level := parseLevel(s); level != expected {
+			t.Errorf("expected %q for %q, got %q", expected, s, level)
+		}
+	}
+}
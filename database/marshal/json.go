@@ -0,0 +1,45 @@
+package db
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+//jsonValue encodes a Go value as a JSON document when passed to the driver
+type jsonValue struct {
+	column string
+	v      interface{}
+}
+
+//JSON wraps v so it's stored as a JSON document, nil is stored as NULL
+func JSON(v interface{}) driver.Valuer {
+	return jsonValue{v: v}
+}
+
+//Value implements driver.Valuer, nil pointers, maps, and slices are NULL rather than the document null
+func (j jsonValue) Value() (driver.Value, error) {
+	if j.v == nil || isNil(reflect.ValueOf(j.v)) {
+		return nil, nil
+	}
+
+	b, err := json.Marshal(j.v)
+	if err != nil {
+		if j.column != "" {
+			return nil, fmt.Errorf("column %q: %v", j.column, err)
+		}
+		return nil, err
+	}
+
+	//mysql rejects JSON documents sent with the binary character set
+	return string(b), nil
+}
+
+func isNil(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		return v.IsNil()
+	}
+	return false
+}
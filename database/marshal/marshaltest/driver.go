@@ -0,0 +1,148 @@
+package marshaltest
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+)
+
+//connector hands database/sql connections that answer from the fake's expectations
+type connector struct {
+	fake *Fake
+}
+
+func (c *connector) Connect(context.Context) (driver.Conn, error) {
+	return &conn{c.fake}, nil
+}
+
+func (c *connector) Driver() driver.Driver {
+	return fakeDriver{}
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) {
+	return nil, errors.New("marshaltest: use marshaltest.New")
+}
+
+type conn struct {
+	fake *Fake
+}
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return &stmt{c.fake, query}, nil
+}
+
+func (c *conn) Close() error {
+	return nil
+}
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return nil, errors.New("marshaltest: transactions are not supported")
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return runQuery(c.fake, query, args)
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return runExec(c.fake, query, args)
+}
+
+type stmt struct {
+	fake  *Fake
+	query string
+}
+
+func (s *stmt) Close() error {
+	return nil
+}
+
+//NumInput returns -1 so database/sql doesn't check the argument count, the expectation does
+func (s *stmt) NumInput() int {
+	return -1
+}
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return runExec(s.fake, s.query, named(args))
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return runQuery(s.fake, s.query, named(args))
+}
+
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return runExec(s.fake, s.query, args)
+}
+
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	return runQuery(s.fake, s.query, args)
+}
+
+func runQuery(f *Fake, sql string, args []driver.NamedValue) (driver.Rows, error) {
+	e, err := f.match(sql, args)
+	if err != nil {
+		return nil, err
+	}
+	return e.driverRows()
+}
+
+func runExec(f *Fake, sql string, args []driver.NamedValue) (driver.Result, error) {
+	e, err := f.match(sql, args)
+	if err != nil {
+		return nil, err
+	}
+
+	if e.result == nil {
+		return result{}, nil
+	}
+	return e.result, nil
+}
+
+func named(args []driver.Value) []driver.NamedValue {
+	var nv = make([]driver.NamedValue, len(args))
+	for i, arg := range args {
+		nv[i] = driver.NamedValue{Ordinal: i + 1, Value: arg}
+	}
+	return nv
+}
+
+//result is returned by updates, inserts, and deletes
+type result struct {
+	lastInsertID int64
+	rowsAffected int64
+}
+
+func (r result) LastInsertId() (int64, error) {
+	return r.lastInsertID, nil
+}
+
+func (r result) RowsAffected() (int64, error) {
+	return r.rowsAffected, nil
+}
+
+//rows iterates over canned driver values
+type rows struct {
+	cols []string
+	vals [][]driver.Value
+	pos  int
+}
+
+func (r *rows) Columns() []string {
+	return r.cols
+}
+
+func (r *rows) Close() error {
+	return nil
+}
+
+func (r *rows) Next(dest []driver.Value) error {
+	if r.pos >= len(r.vals) {
+		return io.EOF
+	}
+
+	copy(dest, r.vals[r.pos])
+	r.pos++
+	return nil
+}
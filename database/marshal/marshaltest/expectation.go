@@ -0,0 +1,177 @@
+package marshaltest
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+
+	db "github.com/random9s/cinder/database/marshal"
+)
+
+//Argument matches a statement argument that can't be compared by value
+type Argument interface {
+	Match(driver.Value) bool
+}
+
+type anyArg struct{}
+
+func (anyArg) Match(driver.Value) bool { return true }
+
+func (anyArg) String() string { return "<any>" }
+
+//Any matches every argument value
+func Any() Argument {
+	return anyArg{}
+}
+
+//Expectation describes a statement the fake expects and how it responds
+type Expectation struct {
+	sql string
+	re  *regexp.Regexp
+
+	args      []interface{}
+	checkArgs bool
+
+	columns []string
+	rows    []interface{}
+	result  driver.Result
+	err     error
+
+	met bool
+}
+
+//WithArgs requires the statement to be run with args, ints of any size compare equal
+func (e *Expectation) WithArgs(args ...interface{}) *Expectation {
+	e.args = args
+	e.checkArgs = true
+	return e
+}
+
+//WillReturnRows responds to the statement with rows, each a struct, pointer to struct, or map[string]interface{}
+//
+//Structs are converted to columns using their `mysql` tags.  Map columns are sorted unless set with WithColumns.
+func (e *Expectation) WillReturnRows(rows ...interface{}) *Expectation {
+	e.rows = rows
+	return e
+}
+
+//WithColumns sets the column order for rows expressed as maps
+func (e *Expectation) WithColumns(cols ...string) *Expectation {
+	e.columns = cols
+	return e
+}
+
+//WillReturnResult responds to an update, insert, or delete with the given ids
+func (e *Expectation) WillReturnResult(lastInsertID, rowsAffected int64) *Expectation {
+	e.result = result{lastInsertID, rowsAffected}
+	return e
+}
+
+//WillReturnError makes the statement fail with err
+func (e *Expectation) WillReturnError(err error) *Expectation {
+	e.err = err
+	return e
+}
+
+//String describes the expected statement
+func (e *Expectation) String() string {
+	var s = fmt.Sprintf("%q", e.sql)
+	if e.re != nil {
+		s = fmt.Sprintf("matching %q", e.re.String())
+	}
+
+	if e.checkArgs {
+		s += fmt.Sprintf(" with args %v", e.args)
+	}
+	return s
+}
+
+func (e *Expectation) matches(query string) bool {
+	if e.re != nil {
+		return e.re.MatchString(query)
+	}
+	return e.sql == normalize(query)
+}
+
+func (e *Expectation) matchArgs(vals []driver.Value) error {
+	if !e.checkArgs {
+		return nil
+	}
+
+	if len(vals) != len(e.args) {
+		return fmt.Errorf("expected %d args %v, got %d args %v", len(e.args), e.args, len(vals), vals)
+	}
+
+	for i, arg := range e.args {
+		if m, ok := arg.(Argument); ok {
+			if !m.Match(vals[i]) {
+				return fmt.Errorf("arg %d: %v does not match %v", i, vals[i], arg)
+			}
+			continue
+		}
+
+		//Convert the expectation the same way database/sql converted the actual argument
+		want, err := driver.DefaultParameterConverter.ConvertValue(arg)
+		if err != nil {
+			//Maps, structs, and slices are compared as the JSON they're stored as in json columns
+			if want, err = db.JSON(arg).Value(); err != nil {
+				return fmt.Errorf("arg %d: %v", i, err)
+			}
+		}
+
+		if !reflect.DeepEqual(want, vals[i]) {
+			return fmt.Errorf("arg %d: expected %v (%T), got %v (%T)", i, want, want, vals[i], vals[i])
+		}
+	}
+
+	return nil
+}
+
+//driverRows converts the canned rows into driver values
+func (e *Expectation) driverRows() (*rows, error) {
+	var r = &rows{cols: e.columns}
+
+	for i, row := range e.rows {
+		var vals map[string]interface{}
+		if m, ok := row.(map[string]interface{}); ok {
+			vals = m
+		} else {
+			cols, vs, err := db.ColumnValues(row)
+			if err != nil {
+				return nil, fmt.Errorf("row %d: %v", i, err)
+			}
+
+			vals = make(map[string]interface{}, len(cols))
+			for j, col := range cols {
+				vals[col] = vs[j]
+			}
+
+			//Struct rows keep their field order
+			if r.cols == nil {
+				r.cols = cols
+			}
+		}
+
+		if r.cols == nil {
+			r.cols = make([]string, 0, len(vals))
+			for col := range vals {
+				r.cols = append(r.cols, col)
+			}
+			sort.Strings(r.cols)
+		}
+
+		var dvs = make([]driver.Value, len(r.cols))
+		for j, col := range r.cols {
+			v, err := driver.DefaultParameterConverter.ConvertValue(vals[col])
+			if err != nil {
+				return nil, fmt.Errorf("row %d column %q: %v", i, col, err)
+			}
+			dvs[j] = v
+		}
+		r.vals = append(r.vals, dvs)
+	}
+
+	return r, nil
+}
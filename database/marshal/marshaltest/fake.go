@@ -0,0 +1,117 @@
+package marshaltest
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	db "github.com/random9s/cinder/database/marshal"
+)
+
+//Fake is an in-memory db.UnmarshalMarshaler programmed with expected statements
+//
+//Statements run through database/sql and the real MySQL marshaler, so canned rows populate
+//targets exactly the way a live database would, including `mysql` tag handling.
+type Fake struct {
+	*db.MySQL
+
+	mu           sync.Mutex
+	expectations []*Expectation
+	unexpected   []error
+}
+
+//New returns a fake with no expectations
+func New() *Fake {
+	var f = new(Fake)
+	f.MySQL = db.NewMySQL(sql.OpenDB(&connector{f}))
+	return f
+}
+
+//Expect registers a statement that must match sql exactly, ignoring differences in whitespace
+func (f *Fake) Expect(sql string) *Expectation {
+	var e = &Expectation{sql: normalize(sql)}
+	f.add(e)
+	return e
+}
+
+//ExpectRegexp registers a statement that must match the regular expression pattern
+func (f *Fake) ExpectRegexp(pattern string) *Expectation {
+	var e = &Expectation{re: regexp.MustCompile(pattern)}
+	f.add(e)
+	return e
+}
+
+//ExpectationsWereMet returns an error describing every expectation that wasn't satisfied
+//and every statement that didn't match an expectation
+func (f *Fake) ExpectationsWereMet() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var msgs = make([]string, 0)
+	for _, e := range f.expectations {
+		if !e.met {
+			msgs = append(msgs, fmt.Sprintf("expected statement %s was not run", e))
+		}
+	}
+
+	for _, err := range f.unexpected {
+		msgs = append(msgs, err.Error())
+	}
+
+	if len(msgs) == 0 {
+		return nil
+	}
+
+	return errors.New("marshaltest: " + strings.Join(msgs, "; "))
+}
+
+func (f *Fake) add(e *Expectation) {
+	f.mu.Lock()
+	f.expectations = append(f.expectations, e)
+	f.mu.Unlock()
+}
+
+//match consumes the first unmet expectation for query, statements are free to run in any order
+func (f *Fake) match(query string, args []driver.NamedValue) (*Expectation, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var vals = make([]driver.Value, len(args))
+	for i, arg := range args {
+		vals[i] = arg.Value
+	}
+
+	//The args error of the first expectation with the same statement is reported if none match
+	var argsErr error
+	for _, e := range f.expectations {
+		if e.met || !e.matches(query) {
+			continue
+		}
+
+		if err := e.matchArgs(vals); err != nil {
+			if argsErr == nil {
+				argsErr = fmt.Errorf("statement %q: %v", query, err)
+			}
+			continue
+		}
+
+		e.met = true
+		return e, e.err
+	}
+
+	var err = argsErr
+	if err == nil {
+		err = fmt.Errorf("unexpected statement %q with args %v", query, vals)
+	}
+	f.unexpected = append(f.unexpected, err)
+	return nil, err
+}
+
+//normalize collapses runs of whitespace so formatting doesn't affect exact matches
+func normalize(sql string) string {
+	return strings.Join(strings.Fields(sql), " ")
+}
@@ -0,0 +1,132 @@
+package marshaltest
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	db "github.com/random9s/cinder/database/marshal"
+)
+
+type user struct {
+	ID      int       `mysql:"id"`
+	Email   string    `mysql:"email"`
+	Name    string    `mysql:"name,omitempty"`
+	Updated time.Time `mysql:"updated_on"`
+	Ignored string
+}
+
+func TestFakeUnmarshalRows(t *testing.T) {
+	var fake = New()
+	var updated = time.Date(2018, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	fake.Expect("SELECT `user`.`id`, `user`.`email`, `user`.`name`, `user`.`updated_on` FROM `user` WHERE (email = ?) ORDER BY `id` ASC").
+		WithArgs("a@b.c").
+		WillReturnRows(
+			user{ID: 1, Email: "a@b.c", Name: "a", Updated: updated},
+			map[string]interface{}{"id": 2, "email": "a@b.c", "name": nil},
+		)
+
+	users, err := db.Select[user]().Where("email = ?", "a@b.c").OrderBy("id").All(fake)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(users) != 2 {
+		t.Fatalf("expected 2 users, got %d", len(users))
+	}
+
+	if users[0].ID != 1 || users[0].Name != "a" || !users[0].Updated.Equal(updated) {
+		t.Errorf("unexpected first user %+v", users[0])
+	}
+
+	if users[1].ID != 2 || users[1].Name != "" {
+		t.Errorf("unexpected second user %+v", users[1])
+	}
+
+	if err := fake.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFakeUnmarshalField(t *testing.T) {
+	var fake = New()
+	fake.ExpectRegexp(`^SELECT COUNT\(\*\) FROM user`).
+		WithArgs(Any()).
+		WillReturnRows(map[string]interface{}{"COUNT(*)": 42})
+
+	var count int64
+	if err := fake.UnmarshalField(&count, "SELECT COUNT(*) FROM user WHERE client_id=?", 7); err != nil {
+		t.Fatal(err)
+	}
+
+	if count != 42 {
+		t.Errorf("expected 42, got %d", count)
+	}
+}
+
+func TestFakeMarshalRow(t *testing.T) {
+	var fake = New()
+	fake.Expect("UPDATE user SET name=? WHERE id=?").
+		WithArgs("b", int64(1)).
+		WillReturnResult(0, 1)
+
+	res, err := fake.MarshalRow("UPDATE user SET name=? WHERE id=?", "b", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := res.(sql.Result).RowsAffected()
+	if err != nil || n != 1 {
+		t.Errorf("expected 1 row affected, got %d %v", n, err)
+	}
+}
+
+func TestFakeSameStatementAnyOrder(t *testing.T) {
+	var fake = New()
+	fake.Expect("DELETE FROM user WHERE id=?").WithArgs(1)
+	fake.Expect("DELETE FROM user WHERE id=?").WithArgs(2)
+
+	for _, id := range []int{2, 1} {
+		if _, err := fake.MarshalRow("DELETE FROM user WHERE id=?", id); err != nil {
+			t.Errorf("id %d: %v", id, err)
+		}
+	}
+
+	if err := fake.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+
+	//Args are compared against the unmet expectations of the statement
+	fake.Expect("DELETE FROM user WHERE id=?").WithArgs(4)
+	if _, err := fake.MarshalRow("DELETE FROM user WHERE id=?", 3); err == nil || !strings.Contains(err.Error(), "arg 0") {
+		t.Errorf("expected argument mismatch, got %v", err)
+	}
+}
+
+func TestFakeUnmetExpectations(t *testing.T) {
+	var fake = New()
+	var failure = errors.New("connection reset")
+
+	fake.Expect("DELETE FROM user WHERE id=?").WithArgs(1)
+	fake.Expect("SELECT 1").WillReturnError(failure)
+
+	if _, err := fake.MarshalRow("DELETE FROM user WHERE id=?", 2); err == nil {
+		t.Error("expected argument mismatch")
+	}
+
+	var one int
+	if err := fake.UnmarshalField(&one, "SELECT 1"); !errors.Is(err, failure) {
+		t.Errorf("expected %v, got %v", failure, err)
+	}
+
+	if err := fake.UnmarshalField(&one, "SELECT 2"); err == nil {
+		t.Error("expected unexpected statement error")
+	}
+
+	if err := fake.ExpectationsWereMet(); err == nil {
+		t.Error("expected unmet expectations")
+	}
+}
@@ -0,0 +1,230 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+//Tag options recognised on `mysql` struct tags
+const (
+	OptPrimaryKey = "pk"         //Identifies the row in Update and Delete, defaults to the "id" column
+	OptVersion    = "version"    //Optimistic lock, checked and incremented by Update and Delete
+	OptSoftDelete = "softdelete" //Set by Delete instead of removing the row, excluded by Select
+	OptJSON       = "json"       //Stored as a JSON document, decoded into the field when read
+)
+
+//ErrStaleObject is returned when the row was changed or deleted since the model was read
+var ErrStaleObject = errors.New("stale object, row was modified or deleted")
+
+//model contains the tagged columns of a struct with special meaning to Update and Delete
+type model struct {
+	val     reflect.Value
+	table   string
+	cols    []column
+	pks     []column
+	version *column
+	deleted *column
+}
+
+//newModel inspects the tags of v, which must be a pointer to a struct
+func newModel(v interface{}) (*model, error) {
+	var ptr = reflect.ValueOf(v)
+	if ptr.Kind() != reflect.Ptr || ptr.IsNil() || ptr.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("could not translate non struct pointer %v", reflect.TypeOf(v))
+	}
+
+	var m = &model{
+		val:   ptr.Elem(),
+		table: tableName(ptr.Elem().Type()),
+		cols:  columnsOf(ptr.Elem().Type()),
+	}
+
+	for i, col := range m.cols {
+		switch {
+		case col.Opts.Contains(OptPrimaryKey):
+			m.pks = append(m.pks, col)
+		case col.Opts.Contains(OptVersion):
+			m.version = &m.cols[i]
+		case col.Opts.Contains(OptSoftDelete):
+			m.deleted = &m.cols[i]
+		}
+	}
+
+	if len(m.pks) == 0 {
+		for _, col := range m.cols {
+			if col.Name == "id" {
+				m.pks = append(m.pks, col)
+			}
+		}
+	}
+
+	if len(m.pks) == 0 {
+		return nil, fmt.Errorf("could not find primary key for %v, tag a field with %q", m.val.Type(), OptPrimaryKey)
+	}
+
+	return m, nil
+}
+
+//isKey reports whether col identifies the row
+func (m *model) isKey(col column) bool {
+	for _, pk := range m.pks {
+		if pk.Index == col.Index {
+			return true
+		}
+	}
+	return false
+}
+
+//softDeleteColumn returns the name of t's soft delete column, if any
+func softDeleteColumn(t reflect.Type) string {
+	for _, col := range columnsOf(t) {
+		if col.Opts.Contains(OptSoftDelete) {
+			return col.Name
+		}
+	}
+	return ""
+}
+
+//where returns the condition matching the model's current row
+func (m *model) where() (string, []interface{}) {
+	var conds = make([]string, 0)
+	var args = make([]interface{}, 0)
+
+	for _, pk := range m.pks {
+		conds = append(conds, quote(pk.Name)+" = ?")
+		args = append(args, pk.value(m.val))
+	}
+
+	if m.version != nil {
+		conds = append(conds, quote(m.version.Name)+" = ?")
+		args = append(args, m.val.Field(m.version.Index).Interface())
+	}
+
+	return strings.Join(conds, " AND "), args
+}
+
+//exec runs the statement and checks the optimistic lock
+func (m *model) exec(mr Marshaler, query string, args []interface{}) error {
+	res, err := mr.MarshalRow(query, args...)
+	if err != nil {
+		return err
+	}
+
+	if m.version == nil {
+		return nil
+	}
+
+	result, ok := res.(sql.Result)
+	if !ok {
+		return fmt.Errorf("could not check version of %v, marshaler returned %T", m.val.Type(), res)
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return ErrStaleObject
+	}
+
+	//Keep the model in sync with the row so it can be updated again
+	var field = m.val.Field(m.version.Index)
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		field.SetInt(field.Int() + 1)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		field.SetUint(field.Uint() + 1)
+	}
+
+	return nil
+}
+
+/*Update writes every mysql tagged field of v to its row
+ *
+ * v must be a pointer to a struct, its row is identified by the fields tagged `pk` (or the "id" column).
+ * When v has a `version` field the update only applies if the version is unchanged, the version is
+ * incremented in both the row and v, and ErrStaleObject is returned when no row matched.
+ */
+func Update(mr Marshaler, v interface{}) error {
+	m, err := newModel(v)
+	if err != nil {
+		return err
+	}
+
+	var sets = make([]string, 0, len(m.cols))
+	var args = make([]interface{}, 0, len(m.cols))
+	for _, col := range m.cols {
+		if m.isKey(col) || col.Opts.Contains(OptVersion) || col.Opts.Contains(OptSoftDelete) {
+			continue
+		}
+
+		sets = append(sets, quote(col.Name)+" = ?")
+		args = append(args, col.value(m.val))
+	}
+
+	if m.version != nil {
+		sets = append(sets, fmt.Sprintf("%s = %s + 1", quote(m.version.Name), quote(m.version.Name)))
+	}
+
+	if len(sets) == 0 {
+		return fmt.Errorf("could not update %v, no columns to set", m.val.Type())
+	}
+
+	where, whereArgs := m.where()
+	var query = fmt.Sprintf("UPDATE %s SET %s WHERE %s", quote(m.table), strings.Join(sets, ", "), where)
+	return m.exec(mr, query, append(args, whereArgs...))
+}
+
+/*Delete removes the row of v
+ *
+ * When v has a `softdelete` field it's set to the current time instead of removing the row.
+ * Versioned models return ErrStaleObject when the row was changed since it was read.
+ */
+func Delete(mr Marshaler, v interface{}) error {
+	m, err := newModel(v)
+	if err != nil {
+		return err
+	}
+
+	where, args := m.where()
+	if m.deleted == nil {
+		return m.exec(mr, fmt.Sprintf("DELETE FROM %s WHERE %s", quote(m.table), where), args)
+	}
+
+	//Rows that are already deleted keep the time they were deleted at
+	where += " AND " + quote(m.deleted.Name) + " IS NULL"
+
+	var now = time.Now()
+	var sets = quote(m.deleted.Name) + " = ?"
+	if m.version != nil {
+		sets += fmt.Sprintf(", %s = %s + 1", quote(m.version.Name), quote(m.version.Name))
+	}
+
+	var query = fmt.Sprintf("UPDATE %s SET %s WHERE %s", quote(m.table), sets, where)
+	err = m.exec(mr, query, append([]interface{}{now}, args...))
+	if err != nil {
+		return err
+	}
+
+	return setTime(m.val.Field(m.deleted.Index), now)
+}
+
+//setTime assigns t to a time.Time, *time.Time, or nullable time field
+func setTime(field reflect.Value, t time.Time) error {
+	switch v := field.Addr().Interface().(type) {
+	case *time.Time:
+		*v = t
+	case **time.Time:
+		*v = &t
+	case sql.Scanner:
+		return v.Scan(t)
+	default:
+		return fmt.Errorf("could not set soft delete time on %v", field.Type())
+	}
+	return nil
+}
@@ -0,0 +1,228 @@
+package db_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	db "github.com/random9s/cinder/database/marshal"
+	"github.com/random9s/cinder/database/marshal/marshaltest"
+)
+
+type post struct {
+	ID      int64     `mysql:"id,pk"`
+	Title   string    `mysql:"title"`
+	Version int       `mysql:"version,version"`
+	Deleted time.Time `mysql:"deleted_at,softdelete"`
+}
+
+func TestUpdateVersion(t *testing.T) {
+	var fake = marshaltest.New()
+	var p = &post{ID: 1, Title: "hello", Version: 3}
+
+	fake.Expect("UPDATE `post` SET `title` = ?, `version` = `version` + 1 WHERE `id` = ? AND `version` = ?").
+		WithArgs("hello", 1, 3).
+		WillReturnResult(0, 1)
+
+	if err := db.Update(fake, p); err != nil {
+		t.Fatal(err)
+	}
+
+	if p.Version != 4 {
+		t.Errorf("expected version 4, got %d", p.Version)
+	}
+
+	fake.ExpectRegexp("^UPDATE `post`").WillReturnResult(0, 0)
+	if err := db.Update(fake, p); err != db.ErrStaleObject {
+		t.Errorf("expected ErrStaleObject, got %v", err)
+	}
+
+	if p.Version != 4 {
+		t.Errorf("stale update changed version to %d", p.Version)
+	}
+
+	if err := fake.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+type note struct {
+	ID   int64  `mysql:"id"`
+	Body string `mysql:"body"`
+}
+
+func TestUpdateFallbackID(t *testing.T) {
+	var fake = marshaltest.New()
+
+	//Without a pk tag the id column is the key, it's matched rather than set
+	fake.Expect("UPDATE `note` SET `body` = ? WHERE `id` = ?").
+		WithArgs("hello", 5).
+		WillReturnResult(0, 1)
+
+	if err := db.Update(fake, &note{ID: 5, Body: "hello"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := fake.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+type draft struct {
+	ID      int64     `mysql:"id,pk"`
+	Title   string    `mysql:"title"`
+	Deleted time.Time `mysql:"deleted_at,softdelete"`
+}
+
+func TestUpdateSoftDeleted(t *testing.T) {
+	var fake = marshaltest.New()
+
+	//Soft deleted rows are hidden from selects, not from updates
+	fake.Expect("UPDATE `draft` SET `title` = ? WHERE `id` = ?").
+		WithArgs("restored", 1).
+		WillReturnResult(0, 1)
+
+	if err := db.Update(fake, &draft{ID: 1, Title: "restored", Deleted: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := fake.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSoftDelete(t *testing.T) {
+	var fake = marshaltest.New()
+	var p = &post{ID: 1, Version: 1}
+
+	fake.Expect("UPDATE `post` SET `deleted_at` = ?, `version` = `version` + 1 WHERE `id` = ? AND `version` = ? AND `deleted_at` IS NULL").
+		WithArgs(marshaltest.Any(), 1, 1).
+		WillReturnResult(0, 1)
+
+	if err := db.Delete(fake, p); err != nil {
+		t.Fatal(err)
+	}
+
+	if p.Deleted.IsZero() || p.Version != 2 {
+		t.Errorf("expected deleted model at version 2, got %+v", p)
+	}
+
+	if err := fake.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSelectExcludesSoftDeleted(t *testing.T) {
+	sql, _, err := db.Select[post]().Where("title = ?", "hello").SQL()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var expected = "SELECT `post`.`id`, `post`.`title`, `post`.`version`, `post`.`deleted_at` FROM `post` WHERE (`post`.`deleted_at` IS NULL) AND (title = ?)"
+	if sql != expected {
+		t.Errorf("expected %q, got %q", expected, sql)
+	}
+
+	sql, _, err = db.Select[post]().WithDeleted().SQL()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = "SELECT `post`.`id`, `post`.`title`, `post`.`version`, `post`.`deleted_at` FROM `post`"
+	if sql != expected {
+		t.Errorf("expected %q, got %q", expected, sql)
+	}
+}
+
+type event struct {
+	ID      int64             `mysql:"id"`
+	Payload map[string]string `mysql:"payload,json"`
+	Tags    []string          `mysql:"tags,json"`
+}
+
+func TestJSONColumns(t *testing.T) {
+	var fake = marshaltest.New()
+
+	fake.ExpectRegexp("^SELECT").WillReturnRows(
+		event{ID: 1, Payload: map[string]string{"a": "b"}, Tags: []string{"x", "y"}},
+		map[string]interface{}{"id": 2, "payload": nil, "tags": "[]"},
+	)
+
+	events, err := db.Select[event]().All(fake)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 2 || events[0].Payload["a"] != "b" || len(events[0].Tags) != 2 {
+		t.Fatalf("unexpected events %+v", events)
+	}
+
+	if events[1].Payload != nil || events[1].Tags == nil {
+		t.Errorf("unexpected second event %+v", events[1])
+	}
+
+	fake.Expect("UPDATE `event` SET `payload` = ?, `tags` = ? WHERE `id` = ?").
+		WithArgs(`{"c":"d"}`, `["z"]`, 1).
+		WillReturnResult(0, 1)
+
+	events[0].Payload = map[string]string{"c": "d"}
+	events[0].Tags = []string{"z"}
+	if err := db.Update(fake, &events[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	//Nil maps and slices are stored as NULL and read back as nil
+	fake.Expect("UPDATE `event` SET `payload` = ?, `tags` = ? WHERE `id` = ?").
+		WithArgs(nil, nil, 1).
+		WillReturnResult(0, 1)
+
+	events[0].Payload, events[0].Tags = nil, nil
+	if err := db.Update(fake, &events[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	if v, err := db.JSON((*event)(nil)).Value(); v != nil || err != nil {
+		t.Errorf("expected NULL for a nil pointer, got %v %v", v, err)
+	}
+
+	fake.ExpectRegexp("^SELECT").WillReturnRows(map[string]interface{}{"id": 3, "payload": "not json"})
+	if _, err := db.Select[event]().All(fake); err == nil || !strings.Contains(err.Error(), `column "payload"`) {
+		t.Errorf("expected error identifying the payload column, got %v", err)
+	}
+
+	if err := fake.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+type counter struct {
+	ID    int64  `mysql:"id"`
+	Small int8   `mysql:"small"`
+	Count uint16 `mysql:"count"`
+}
+
+func TestNarrowColumns(t *testing.T) {
+	var fake = marshaltest.New()
+
+	fake.ExpectRegexp("^SELECT").WillReturnRows(map[string]interface{}{"id": 1, "small": 127, "count": 65535})
+	counters, err := db.Select[counter]().All(fake)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(counters) != 1 || counters[0].Small != 127 || counters[0].Count != 65535 {
+		t.Errorf("unexpected counters %+v", counters)
+	}
+
+	var overflows = []map[string]interface{}{
+		{"id": 2, "small": 300, "count": 1},
+		{"id": 3, "small": 1, "count": -1},
+	}
+
+	for _, row := range overflows {
+		fake.ExpectRegexp("^SELECT").WillReturnRows(row)
+		if _, err := db.Select[counter]().All(fake); err == nil || !strings.Contains(err.Error(), "column") {
+			t.Errorf("expected overflow error naming the column for %v, got %v", row, err)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package db
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"reflect"
 	"time"
@@ -21,40 +22,31 @@ func NewMySQL(db *sql.DB) *MySQL {
 
 //MarshalRow updates or deletes a row in a mysql database
 func (db *MySQL) MarshalRow(sql string, args ...interface{}) (interface{}, error) {
-	stmt, err := db.Prepare(sql)
-	if err != nil {
-		return nil, err
-	}
-
-	return stmt.Exec(args...)
+	return db.exec(sql, args)
 }
 
 //MarshalRows updates or deletes many rows in a mysql database
 func (db *MySQL) MarshalRows(sql string, args ...interface{}) (interface{}, error) {
-	stmt, err := db.Prepare(sql)
-	if err != nil {
-		return nil, err
-	}
-
-	return stmt.Exec(args...)
+	return db.exec(sql, args)
 }
 
 //MarshalField updates or deletes a rows field in a mysql database
 func (db *MySQL) MarshalField(sql string, args ...interface{}) (interface{}, error) {
-	stmt, err := db.Prepare(sql)
-	if err != nil {
-		return nil, err
-	}
-
-	return stmt.Exec(args...)
+	return db.exec(sql, args)
 }
 
 //MarshalFields updates or deletes many fields in a mysql database
 func (db *MySQL) MarshalFields(sql string, args ...interface{}) (interface{}, error) {
+	return db.exec(sql, args)
+}
+
+//exec prepares and runs a statement, wrap arguments with JSON to store them as JSON documents
+func (db *MySQL) exec(sql string, args []interface{}) (interface{}, error) {
 	stmt, err := db.Prepare(sql)
 	if err != nil {
 		return nil, err
 	}
+	defer stmt.Close()
 
 	return stmt.Exec(args...)
 }
@@ -275,6 +267,8 @@ type metaStruct struct {
 	StructField reflect.StructField
 	NullValue   interface{}
 	Val         reflect.Value
+	Column      string
+	JSON        bool
 }
 
 func structToMS(cols []string, obj interface{}) ([]*metaStruct, error) {
@@ -308,24 +302,30 @@ func structToMS(cols []string, obj interface{}) ([]*metaStruct, error) {
 			}
 
 			//Check if the current struct field has a tag called translate
-			if fieldName, ok := fieldInfo.Tag.Lookup("mysql"); ok {
+			if tag, ok := fieldInfo.Tag.Lookup("mysql"); ok {
+				//Options such as `mysql:"name,opt"` are not part of the column name
+				fieldName, opts := parseTag(tag)
+
 				//Look for this field in the columns that the query selected
 				for j, col := range cols {
 					if col == fieldName {
 						//Set scan value to int, string, or bool
 						var nullType interface{}
 
-						switch fieldInfo.Type {
-						case typeBool():
+						switch {
+						case opts.Contains(OptJSON):
+							//Decoded into the field by assignValsToStruct
+							nullType = new(sql.RawBytes)
+						case fieldInfo.Type == typeBool():
 							nullType = new(sql.NullBool)
-						case typeInt(int(0)), typeInt(int8(0)), typeInt(int16(0)), typeInt(int32(0)), typeInt(int64(0)),
-							typeInt(uint(0)), typeInt(uint8(0)), typeInt(uint16(0)), typeInt(uint32(0)), typeInt(uint64(0)), typeInt(uintptr(0)):
+						case isType(fieldInfo.Type, typeInt(int(0)), typeInt(int8(0)), typeInt(int16(0)), typeInt(int32(0)), typeInt(int64(0)),
+							typeInt(uint(0)), typeInt(uint8(0)), typeInt(uint16(0)), typeInt(uint32(0)), typeInt(uint64(0)), typeInt(uintptr(0))):
 							nullType = new(sql.NullInt64)
-						case typeFloat(float32(0.0)), typeFloat(float64(0.0)):
+						case isType(fieldInfo.Type, typeFloat(float32(0.0)), typeFloat(float64(0.0))):
 							nullType = new(sql.NullFloat64)
-						case typeString():
+						case fieldInfo.Type == typeString():
 							nullType = new(sql.NullString)
-						case typeTime():
+						case fieldInfo.Type == typeTime():
 							nullType = new(mysql.NullTime)
 						default:
 							nullType = new(sql.RawBytes)
@@ -335,6 +335,8 @@ func structToMS(cols []string, obj interface{}) ([]*metaStruct, error) {
 						ms[j] = &metaStruct{
 							NullValue: nullType,
 							Val:       fieldVal,
+							Column:    col,
+							JSON:      opts.Contains(OptJSON),
 						}
 					}
 				}
@@ -353,10 +355,26 @@ func assignValsToStruct(ms []*metaStruct) error {
 			continue
 		}
 
+		//Raw columns have no Valid field, they're nil when NULL
+		if raw, ok := sf.NullValue.(*sql.RawBytes); ok {
+			if err := assignRawBytes(sf, *raw); err != nil {
+				return err
+			}
+			continue
+		}
+
 		nullVal := reflect.ValueOf(sf.NullValue).Elem()
 		//Equivalent to Null<T>.Valid
 		if nullVal.Field(1).Bool() {
 			setVal := nullVal.Field(0)
+			//Null types hold the widest type (int64, float64), narrow them to the field's type
+			if setVal.Type() != sf.Val.Type() {
+				narrowed, err := narrow(setVal, sf.Val.Type())
+				if err != nil {
+					return fmt.Errorf("column %q: %v", sf.Column, err)
+				}
+				setVal = narrowed
+			}
 			sf.Val.Set(setVal)
 		}
 	}
@@ -364,6 +382,59 @@ func assignValsToStruct(ms []*metaStruct) error {
 	return nil
 }
 
+//narrow converts v to t, it's an error if the value doesn't fit
+func narrow(v reflect.Value, t reflect.Type) (reflect.Value, error) {
+	if !v.Type().ConvertibleTo(t) {
+		return v, fmt.Errorf("could not assign %v to %v", v.Type(), t)
+	}
+
+	var overflows bool
+	var target = reflect.New(t).Elem()
+	switch v.Kind() {
+	case reflect.Int64:
+		switch t.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			overflows = target.OverflowInt(v.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			overflows = v.Int() < 0 || target.OverflowUint(uint64(v.Int()))
+		}
+	case reflect.Float64:
+		switch t.Kind() {
+		case reflect.Float32, reflect.Float64:
+			overflows = target.OverflowFloat(v.Float())
+		}
+	}
+
+	if overflows {
+		return v, fmt.Errorf("value %v overflows %v", v, t)
+	}
+	return v.Convert(t), nil
+}
+
+//assignRawBytes decodes JSON columns and copies byte slices, raw bytes are only valid until the next scan
+func assignRawBytes(sf *metaStruct, raw sql.RawBytes) error {
+	if raw == nil {
+		return nil
+	}
+
+	if sf.JSON {
+		//Decode into a fresh value so fields missing from the document aren't left over from a previous row
+		var v = reflect.New(sf.Val.Type())
+		if err := json.Unmarshal(raw, v.Interface()); err != nil {
+			return fmt.Errorf("column %q: %v", sf.Column, err)
+		}
+		sf.Val.Set(v.Elem())
+		return nil
+	}
+
+	if sf.Val.Kind() == reflect.Slice && sf.Val.Type().Elem().Kind() == reflect.Uint8 {
+		sf.Val.SetBytes(append([]byte(nil), raw...))
+		return nil
+	}
+
+	return fmt.Errorf("column %q: could not assign to %v, tag the field with %q for JSON columns", sf.Column, sf.Val.Type(), OptJSON)
+}
+
 //nullValues populates a null value table for the corresponding fields in the provided sql statement
 func nullValues(ms []*metaStruct) []interface{} {
 	var nullValues = make([]interface{}, 0)
@@ -393,6 +464,15 @@ func typeTime() reflect.Type {
 	return reflect.TypeOf(t)
 }
 
+func isType(t reflect.Type, types ...reflect.Type) bool {
+	for _, typ := range types {
+		if t == typ {
+			return true
+		}
+	}
+	return false
+}
+
 func typeInt(i interface{}) reflect.Type {
 	return reflect.TypeOf(i)
 }
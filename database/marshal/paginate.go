@@ -0,0 +1,274 @@
+package db
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+//ErrInvalidCursor is returned when a cursor was tampered with or was issued for a different ordering
+var ErrInvalidCursor = errors.New("invalid pagination cursor")
+
+//cursorTime is the format time keys are stored in UTC, mysql compares it directly against DATETIME columns
+const cursorTime = "2006-01-02 15:04:05.999999"
+
+/*Pagination describes which page of results to fetch
+ *
+ * Modes:
+ *  Offset: When Keys is empty the page starts at Offset and the total number of matching rows is counted.
+ *  Keyset: When Keys is set, rows are ordered by Keys and the page resumes after the row Cursor points to.
+ *          Cursors are signed with Secret so clients can't forge or modify them.
+ */
+type Pagination struct {
+	Limit  int
+	Offset int
+
+	Keys   []string //Ordered columns, each optionally followed by ASC or DESC. The last key must be unique.
+	Cursor string   //NextCursor from the previous page, empty for the first page
+	Secret []byte   //Key used to sign cursors
+}
+
+//Page contains a single page of results
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	Total      int64  `json:"total,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+}
+
+//Paginate runs q for the page described by p
+func Paginate[T any](u Unmarshaler, q *Query[T], p Pagination) (*Page[T], error) {
+	if p.Limit <= 0 {
+		return nil, fmt.Errorf("invalid page limit %d", p.Limit)
+	}
+
+	if len(p.Keys) == 0 {
+		return paginateOffset(u, q, p)
+	}
+
+	return paginateKeyset(u, q, p)
+}
+
+func paginateOffset[T any](u Unmarshaler, q *Query[T], p Pagination) (*Page[T], error) {
+	if p.Offset < 0 {
+		return nil, fmt.Errorf("invalid page offset %d", p.Offset)
+	}
+
+	countSQL, args, err := q.countSQL()
+	if err != nil {
+		return nil, err
+	}
+
+	var page = new(Page[T])
+	err = u.UnmarshalField(&page.Total, countSQL, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var c = q.clone()
+	c.limit = p.Limit
+	c.offset = p.Offset
+
+	page.Items, err = c.All(u)
+	if err != nil {
+		return nil, err
+	}
+
+	page.HasMore = int64(p.Offset+len(page.Items)) < page.Total
+	return page, nil
+}
+
+func paginateKeyset[T any](u Unmarshaler, q *Query[T], p Pagination) (*Page[T], error) {
+	if len(p.Secret) == 0 {
+		return nil, errors.New("keyset pagination requires a secret")
+	}
+
+	//Keys decide the order and the cursor the start, an Offset or OrderBy would silently be ignored
+	if p.Offset != 0 {
+		return nil, errors.New("keyset pagination can't be used with an offset")
+	}
+
+	if len(q.order) > 0 {
+		return nil, errors.New("keyset pagination orders by its keys, remove OrderBy from the query")
+	}
+
+	//Look up the struct field holding each key so the next cursor can be read from the last row
+	var fields = make(map[string]int)
+	for _, col := range columnsOf(reflect.TypeOf((*T)(nil)).Elem()) {
+		fields[col.Name] = col.Index
+	}
+
+	var keys = make([]string, len(p.Keys))
+	var desc = make([]bool, len(p.Keys))
+	var index = make([]int, len(p.Keys))
+	for i, key := range p.Keys {
+		name, d, err := parseOrder(key)
+		if err != nil {
+			return nil, err
+		}
+
+		//Keys of joined tables can't be read from the model to build the cursor
+		var column = name
+		if i := strings.IndexByte(name, '.'); i >= 0 {
+			if name[:i] != q.table {
+				return nil, fmt.Errorf("pagination key %q is not a column of %s", key, q.table)
+			}
+			column = name[i+1:]
+		}
+
+		idx, ok := fields[column]
+		if !ok {
+			return nil, fmt.Errorf("pagination key %q is not a mysql tagged field", key)
+		}
+
+		keys[i], desc[i], index[i] = name, d, idx
+	}
+
+	var c = q.clone()
+	c.order = nil
+	c.OrderBy(p.Keys...)
+	//Fetch an extra row to find out if there's another page
+	c.limit = p.Limit + 1
+	c.offset = 0
+
+	if p.Cursor != "" {
+		vals, err := decodeCursor(p.Cursor, p.Keys, p.Secret)
+		if err != nil {
+			return nil, err
+		}
+
+		cond, args := keysetCondition(keys, desc, vals)
+		c.where = append(c.where, clause{cond, args})
+	}
+
+	items, err := c.All(u)
+	if err != nil {
+		return nil, err
+	}
+
+	var page = &Page[T]{Items: items}
+	if len(items) > p.Limit {
+		page.Items = items[:p.Limit]
+		page.HasMore = true
+
+		var last = reflect.ValueOf(&page.Items[p.Limit-1]).Elem()
+		var vals = make([]interface{}, len(index))
+		for i, idx := range index {
+			vals[i] = last.Field(idx).Interface()
+		}
+
+		page.NextCursor, err = encodeCursor(p.Keys, vals, p.Secret)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return page, nil
+}
+
+/*keysetCondition returns the condition selecting rows after vals in the order described by keys
+ *
+ * Mixed sort directions rule out a row comparison, so the condition is expanded:
+ *  (a > ?) OR (a = ? AND b < ?) OR (a = ? AND b = ? AND c > ?)
+ */
+func keysetCondition(keys []string, desc []bool, vals []interface{}) (string, []interface{}) {
+	var ors = make([]string, len(keys))
+	var args = make([]interface{}, 0)
+
+	for i := range keys {
+		var ands = make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			ands = append(ands, quote(keys[j])+" = ?")
+			args = append(args, vals[j])
+		}
+
+		var op = " > ?"
+		if desc[i] {
+			op = " < ?"
+		}
+		ands = append(ands, quote(keys[i])+op)
+		args = append(args, vals[i])
+
+		ors[i] = "(" + strings.Join(ands, " AND ") + ")"
+	}
+
+	return strings.Join(ors, " OR "), args
+}
+
+//cursor is the signed payload of a keyset cursor, the keys tie it to the ordering it was issued for
+type cursor struct {
+	Keys []string      `json:"k"`
+	Vals []interface{} `json:"v"`
+}
+
+//encodeCursor returns base64(payload).base64(hmac)
+func encodeCursor(keys []string, vals []interface{}, secret []byte) (string, error) {
+	for i, v := range vals {
+		if t, ok := v.(time.Time); ok {
+			vals[i] = t.UTC().Format(cursorTime)
+		}
+	}
+
+	payload, err := json.Marshal(&cursor{keys, vals})
+	if err != nil {
+		return "", err
+	}
+
+	var enc = base64.RawURLEncoding
+	return enc.EncodeToString(payload) + "." + enc.EncodeToString(sign(payload, secret)), nil
+}
+
+//decodeCursor verifies the cursor's signature and returns the key values it contains
+func decodeCursor(s string, keys []string, secret []byte) ([]interface{}, error) {
+	var enc = base64.RawURLEncoding
+
+	var spl = strings.Split(s, ".")
+	if len(spl) != 2 {
+		return nil, ErrInvalidCursor
+	}
+
+	payload, err := enc.DecodeString(spl[0])
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	mac, err := enc.DecodeString(spl[1])
+	if err != nil || !hmac.Equal(mac, sign(payload, secret)) {
+		return nil, ErrInvalidCursor
+	}
+
+	var c cursor
+	var dec = json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+	if err := dec.Decode(&c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	if !reflect.DeepEqual(c.Keys, keys) || len(c.Vals) != len(keys) {
+		return nil, ErrInvalidCursor
+	}
+
+	//Numbers keep their integer precision instead of becoming float64
+	for i, v := range c.Vals {
+		if n, ok := v.(json.Number); ok {
+			if c.Vals[i], err = n.Int64(); err != nil {
+				c.Vals[i], _ = n.Float64()
+			}
+		}
+	}
+
+	return c.Vals, nil
+}
+
+func sign(payload, secret []byte) []byte {
+	var h = hmac.New(sha256.New, secret)
+	h.Write(payload)
+	return h.Sum(nil)
+}
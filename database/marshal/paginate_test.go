@@ -0,0 +1,169 @@
+package db
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+//pageStub returns canned users and records the statements it receives
+type pageStub struct {
+	total   int64
+	users   []queryUser
+	queries []string
+	args    [][]interface{}
+}
+
+func (s *pageStub) UnmarshalRow(v interface{}, sql string, args ...interface{}) error { return nil }
+
+func (s *pageStub) UnmarshalRows(v interface{}, sql string, args ...interface{}) error {
+	s.queries = append(s.queries, sql)
+	s.args = append(s.args, args)
+	*(v.(*[]queryUser)) = append([]queryUser(nil), s.users...)
+	return nil
+}
+
+func (s *pageStub) UnmarshalField(v interface{}, sql string, args ...interface{}) error {
+	s.queries = append(s.queries, sql)
+	s.args = append(s.args, args)
+	*(v.(*int64)) = s.total
+	return nil
+}
+
+func (s *pageStub) UnmarshalFields(v interface{}, sql string, args ...interface{}) error { return nil }
+
+func TestPaginateOffset(t *testing.T) {
+	var stub = &pageStub{total: 3, users: []queryUser{{ID: 1}, {ID: 2}}}
+
+	page, err := Paginate(stub, Select[queryUser]().Where("email LIKE ?", "%@b.c").OrderBy("id"), Pagination{Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if page.Total != 3 || !page.HasMore || len(page.Items) != 2 {
+		t.Errorf("unexpected page %+v", page)
+	}
+
+	if stub.queries[0] != "SELECT COUNT(*) FROM `user` WHERE (email LIKE ?)" {
+		t.Errorf("unexpected count query %q", stub.queries[0])
+	}
+
+	if !strings.HasSuffix(stub.queries[1], "ORDER BY `id` ASC LIMIT 2") {
+		t.Errorf("unexpected page query %q", stub.queries[1])
+	}
+}
+
+func TestPaginateKeyset(t *testing.T) {
+	var secret = []byte("secret")
+	var stub = &pageStub{users: []queryUser{{ID: 1, Email: "b"}, {ID: 2, Email: "a"}, {ID: 3, Email: "a"}}}
+	var p = Pagination{Limit: 2, Keys: []string{"email DESC", "id"}, Secret: secret}
+
+	page, err := Paginate(stub, Select[queryUser](), p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !page.HasMore || len(page.Items) != 2 || page.NextCursor == "" {
+		t.Fatalf("unexpected first page %+v", page)
+	}
+
+	if !strings.HasSuffix(stub.queries[0], "ORDER BY `email` DESC, `id` ASC LIMIT 3") {
+		t.Errorf("unexpected first page query %q", stub.queries[0])
+	}
+
+	stub.users = stub.users[2:]
+	p.Cursor = page.NextCursor
+	page, err = Paginate(stub, Select[queryUser](), p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if page.HasMore || len(page.Items) != 1 || page.NextCursor != "" {
+		t.Errorf("unexpected last page %+v", page)
+	}
+
+	if !strings.Contains(stub.queries[1], "WHERE ((`email` < ?) OR (`email` = ? AND `id` > ?))") {
+		t.Errorf("unexpected keyset query %q", stub.queries[1])
+	}
+
+	if !reflect.DeepEqual(stub.args[1], []interface{}{"a", "a", int64(2)}) {
+		t.Errorf("unexpected keyset args %v", stub.args[1])
+	}
+}
+
+func TestPaginateKeysetErrors(t *testing.T) {
+	var tests = []struct {
+		description string
+		query       *Query[queryUser]
+		p           Pagination
+	}{
+		{"offset", Select[queryUser](), Pagination{Limit: 1, Offset: 10, Keys: []string{"id"}}},
+		{"order by", Select[queryUser]().OrderBy("email"), Pagination{Limit: 1, Keys: []string{"id"}}},
+		{"joined table key", Select[queryUser]().Join("orders", "orders.user_id = user.id"), Pagination{Limit: 1, Keys: []string{"orders.id"}}},
+		{"unknown key", Select[queryUser](), Pagination{Limit: 1, Keys: []string{"missing"}}},
+	}
+
+	for _, test := range tests {
+		test.p.Secret = []byte("secret")
+		if _, err := Paginate(&pageStub{}, test.query, test.p); err == nil {
+			t.Errorf("%s: expected error", test.description)
+		}
+	}
+
+	var stub = &pageStub{}
+	if _, err := Paginate(stub, Select[queryUser](), Pagination{Limit: 1, Keys: []string{"user.id"}, Secret: []byte("secret")}); err != nil {
+		t.Errorf("expected key qualified by the model's table to be accepted, got %v", err)
+	}
+}
+
+func TestCursorTimeUTC(t *testing.T) {
+	var instant = time.Date(2018, 1, 2, 3, 4, 5, 0, time.UTC)
+	var keys = []string{"updated_on"}
+
+	utc, err := encodeCursor(keys, []interface{}{instant}, []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	local, err := encodeCursor(keys, []interface{}{instant.In(time.FixedZone("EST", -5*3600))}, []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if utc != local {
+		t.Error("expected the same cursor for the same instant in any location")
+	}
+
+	vals, err := decodeCursor(utc, keys, []byte("secret"))
+	if err != nil || len(vals) != 1 || vals[0] != "2018-01-02 03:04:05" {
+		t.Errorf("expected the UTC time, got %v %v", vals, err)
+	}
+}
+
+func TestPaginateInvalidCursor(t *testing.T) {
+	var keys = []string{"id"}
+	cur, err := encodeCursor(keys, []interface{}{int64(10)}, []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = []struct {
+		description string
+		cursor      string
+		keys        []string
+		secret      string
+	}{
+		{"wrong secret", cur, keys, "other"},
+		{"different ordering", cur, []string{"id DESC"}, "secret"},
+		{"modified payload", "x" + cur, keys, "secret"},
+		{"garbage", "not-a-cursor", keys, "secret"},
+	}
+
+	for _, test := range tests {
+		_, err := Paginate(&pageStub{}, Select[queryUser](), Pagination{Limit: 1, Keys: test.keys, Cursor: test.cursor, Secret: []byte(test.secret)})
+		if err != ErrInvalidCursor {
+			t.Errorf("%s: expected ErrInvalidCursor, got %v", test.description, err)
+		}
+	}
+}
@@ -0,0 +1,346 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+//Tabler is implemented by models that are stored in a table other than their snake cased struct name
+type Tabler interface {
+	TableName() string
+}
+
+//identifier matches a column or table name, optionally qualified by a table (table.column)
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_$]*(\.[A-Za-z_][A-Za-z0-9_$]*)?$`)
+
+//Query builds a SELECT statement whose column list is derived from the mysql tags of T
+//
+//Identifiers passed to the builder are validated and quoted, values are always passed as
+//placeholder arguments.  The first error encountered is kept and returned by SQL, All, and One.
+type Query[T any] struct {
+	table   string
+	columns []string
+	joins   []clause
+	where   []clause
+	order   []string
+	limit   int
+	offset  int
+	err     error
+
+	softDelete  string //Soft delete column of T, rows where it's set are excluded
+	withDeleted bool
+}
+
+//clause is a piece of sql and the arguments bound to its placeholders
+type clause struct {
+	sql  string
+	args []interface{}
+}
+
+//Select returns a query selecting every mysql tagged field of T
+func Select[T any]() *Query[T] {
+	var q = new(Query[T])
+
+	var t = reflect.TypeOf((*T)(nil)).Elem()
+	if t.Kind() != reflect.Struct {
+		q.err = fmt.Errorf("could not build query for non struct %v", t)
+		return q
+	}
+
+	q.table = tableName(t)
+	q.softDelete = softDeleteColumn(t)
+	for _, col := range columnsOf(t) {
+		q.columns = append(q.columns, col.Name)
+	}
+
+	if len(q.columns) == 0 {
+		q.err = fmt.Errorf("could not build query for %v, no mysql tagged fields", t)
+	}
+
+	return q
+}
+
+//From overrides the table being selected from
+func (q *Query[T]) From(table string) *Query[T] {
+	q.table = table
+	return q
+}
+
+//Where adds a condition to the query, multiple conditions are joined with AND
+func (q *Query[T]) Where(cond string, args ...interface{}) *Query[T] {
+	if n := placeholders(cond); n != len(args) {
+		q.setErr(fmt.Errorf("where %q expects %d arguments, got %d", cond, n, len(args)))
+		return q
+	}
+
+	q.where = append(q.where, clause{cond, args})
+	return q
+}
+
+//WithDeleted includes soft deleted rows in the results
+func (q *Query[T]) WithDeleted() *Query[T] {
+	q.withDeleted = true
+	return q
+}
+
+//Join adds an inner join on table using the on condition
+func (q *Query[T]) Join(table, on string, args ...interface{}) *Query[T] {
+	return q.join("JOIN", table, on, args)
+}
+
+//LeftJoin adds a left join on table using the on condition
+func (q *Query[T]) LeftJoin(table, on string, args ...interface{}) *Query[T] {
+	return q.join("LEFT JOIN", table, on, args)
+}
+
+func (q *Query[T]) join(kind, table, on string, args []interface{}) *Query[T] {
+	if !identifier.MatchString(table) {
+		q.setErr(fmt.Errorf("invalid join table %q", table))
+		return q
+	}
+
+	if n := placeholders(on); n != len(args) {
+		q.setErr(fmt.Errorf("join %q expects %d arguments, got %d", on, n, len(args)))
+		return q
+	}
+
+	q.joins = append(q.joins, clause{fmt.Sprintf("%s %s ON %s", kind, quote(table), on), args})
+	return q
+}
+
+//placeholders counts the ? in sql outside of quoted strings and identifiers, such as payload->'$.a?'
+func placeholders(sql string) int {
+	var n int
+	var quote rune
+	var escaped bool
+	for _, r := range sql {
+		switch {
+		case escaped:
+			escaped = false
+		case quote != 0 && r == '\\' && quote != '`':
+			escaped = true
+		case quote != 0:
+			//A doubled quote closes and reopens the string, so it's counted correctly
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+		case r == '?':
+			n++
+		}
+	}
+	return n
+}
+
+//OrderBy sorts the results by one or more columns, each optionally followed by ASC or DESC
+func (q *Query[T]) OrderBy(cols ...string) *Query[T] {
+	for _, col := range cols {
+		name, desc, err := parseOrder(col)
+		if err != nil {
+			q.setErr(err)
+			return q
+		}
+
+		if desc {
+			q.order = append(q.order, quote(name)+" DESC")
+		} else {
+			q.order = append(q.order, quote(name)+" ASC")
+		}
+	}
+	return q
+}
+
+//Limit restricts the number of rows returned
+func (q *Query[T]) Limit(n int) *Query[T] {
+	if n < 0 {
+		q.setErr(fmt.Errorf("invalid limit %d", n))
+		return q
+	}
+
+	q.limit = n
+	return q
+}
+
+//Offset skips the first n rows, it requires a limit to be set
+func (q *Query[T]) Offset(n int) *Query[T] {
+	if n < 0 {
+		q.setErr(fmt.Errorf("invalid offset %d", n))
+		return q
+	}
+
+	q.offset = n
+	return q
+}
+
+//SQL returns the generated statement and its arguments
+func (q *Query[T]) SQL() (string, []interface{}, error) {
+	if q.err != nil {
+		return "", nil, q.err
+	}
+
+	if !identifier.MatchString(q.table) {
+		return "", nil, fmt.Errorf("invalid table %q", q.table)
+	}
+
+	if q.offset > 0 && q.limit == 0 {
+		return "", nil, fmt.Errorf("offset %d requires a limit", q.offset)
+	}
+
+	var cols = make([]string, len(q.columns))
+	for i, col := range q.columns {
+		if !identifier.MatchString(col) {
+			return "", nil, fmt.Errorf("invalid column %q", col)
+		}
+		cols[i] = quote(q.table) + "." + quote(col)
+	}
+
+	var buff strings.Builder
+	buff.WriteString("SELECT ")
+	buff.WriteString(strings.Join(cols, ", "))
+
+	var args = make([]interface{}, 0)
+	args = append(args, q.writeFrom(&buff)...)
+
+	if len(q.order) > 0 {
+		buff.WriteString(" ORDER BY ")
+		buff.WriteString(strings.Join(q.order, ", "))
+	}
+
+	if q.limit > 0 {
+		buff.WriteString(" LIMIT " + strconv.Itoa(q.limit))
+	}
+
+	if q.offset > 0 {
+		buff.WriteString(" OFFSET " + strconv.Itoa(q.offset))
+	}
+
+	return buff.String(), args, nil
+}
+
+//countSQL returns a statement counting every row the query matches, ignoring order, limit and offset
+func (q *Query[T]) countSQL() (string, []interface{}, error) {
+	if q.err != nil {
+		return "", nil, q.err
+	}
+
+	if !identifier.MatchString(q.table) {
+		return "", nil, fmt.Errorf("invalid table %q", q.table)
+	}
+
+	var buff strings.Builder
+	buff.WriteString("SELECT COUNT(*)")
+	var args = q.writeFrom(&buff)
+	return buff.String(), args, nil
+}
+
+//writeFrom writes the FROM, JOIN and WHERE clauses shared by every statement built from q
+func (q *Query[T]) writeFrom(buff *strings.Builder) []interface{} {
+	var args = make([]interface{}, 0)
+
+	buff.WriteString(" FROM ")
+	buff.WriteString(quote(q.table))
+
+	for _, j := range q.joins {
+		buff.WriteString(" ")
+		buff.WriteString(j.sql)
+		args = append(args, j.args...)
+	}
+
+	var where = q.where
+	if q.softDelete != "" && !q.withDeleted {
+		where = append([]clause{{quote(q.table) + "." + quote(q.softDelete) + " IS NULL", nil}}, where...)
+	}
+
+	for i, w := range where {
+		if i == 0 {
+			buff.WriteString(" WHERE ")
+		} else {
+			buff.WriteString(" AND ")
+		}
+		buff.WriteString("(" + w.sql + ")")
+		args = append(args, w.args...)
+	}
+
+	return args
+}
+
+//All runs the query and unmarshals every row
+func (q *Query[T]) All(u Unmarshaler) ([]T, error) {
+	query, args, err := q.SQL()
+	if err != nil {
+		return nil, err
+	}
+
+	var rows = make([]T, 0)
+	err = u.UnmarshalRows(&rows, query, args...)
+	return rows, err
+}
+
+//One runs the query and unmarshals the first row, sql.ErrNoRows is returned when nothing matched
+func (q *Query[T]) One(u Unmarshaler) (*T, error) {
+	var c = q.clone()
+	c.limit = 1
+
+	rows, err := c.All(u)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rows) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return &rows[0], nil
+}
+
+//clone returns a copy of q that can be modified without affecting q
+func (q *Query[T]) clone() *Query[T] {
+	var c = *q
+	c.columns = append([]string(nil), q.columns...)
+	c.joins = append([]clause(nil), q.joins...)
+	c.where = append([]clause(nil), q.where...)
+	c.order = append([]string(nil), q.order...)
+	return &c
+}
+
+//setErr keeps the first error encountered while building
+func (q *Query[T]) setErr(err error) {
+	if q.err == nil {
+		q.err = err
+	}
+}
+
+//parseOrder splits "column [ASC|DESC]" into the column and whether it's descending
+func parseOrder(s string) (string, bool, error) {
+	var spl = strings.Fields(s)
+	if len(spl) == 0 || len(spl) > 2 || !identifier.MatchString(spl[0]) {
+		return "", false, fmt.Errorf("invalid order by %q", s)
+	}
+
+	if len(spl) == 1 {
+		return spl[0], false, nil
+	}
+
+	switch strings.ToUpper(spl[1]) {
+	case "ASC":
+		return spl[0], false, nil
+	case "DESC":
+		return spl[0], true, nil
+	}
+
+	return "", false, fmt.Errorf("invalid order by direction %q", s)
+}
+
+//quote wraps an identifier in backticks, quoting each part of a qualified name
+func quote(name string) string {
+	var spl = strings.Split(name, ".")
+	for i, s := range spl {
+		spl[i] = "`" + s + "`"
+	}
+	return strings.Join(spl, ".")
+}
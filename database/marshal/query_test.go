@@ -0,0 +1,110 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+)
+
+type queryUser struct {
+	ID       int64  `mysql:"id"`
+	Email    string `mysql:"email"`
+	Name     string `mysql:"name,omitempty"`
+	Password string
+	internal string `mysql:"internal"`
+}
+
+func (queryUser) TableName() string { return "user" }
+
+type sqler interface {
+	SQL() (string, []interface{}, error)
+}
+
+type AccountHTTPLog struct {
+	ID int64 `mysql:"id"`
+}
+
+func TestSelectSQL(t *testing.T) {
+	tests := []struct {
+		description  string
+		query        sqler
+		expectedSQL  string
+		expectedArgs []interface{}
+	}{
+		{
+			description: "columns derived from tags",
+			query:       Select[queryUser](),
+			expectedSQL: "SELECT `user`.`id`, `user`.`email`, `user`.`name` FROM `user`",
+		}, {
+			description: "table derived from struct name",
+			query:       Select[AccountHTTPLog](),
+			expectedSQL: "SELECT `account_http_log`.`id` FROM `account_http_log`",
+		}, {
+			description:  "where, order, limit and offset",
+			query:        Select[queryUser]().Where("email = ?", "a@b.c").Where("id > ? OR id < ?", 1, 10).OrderBy("id DESC", "name").Limit(10).Offset(20),
+			expectedSQL:  "SELECT `user`.`id`, `user`.`email`, `user`.`name` FROM `user` WHERE (email = ?) AND (id > ? OR id < ?) ORDER BY `id` DESC, `name` ASC LIMIT 10 OFFSET 20",
+			expectedArgs: []interface{}{"a@b.c", 1, 10},
+		}, {
+			description:  "join arguments precede where arguments",
+			query:        Select[queryUser]().Where("user.id = ?", 5).Join("account", "account.user_id = user.id AND account.active = ?", true),
+			expectedSQL:  "SELECT `user`.`id`, `user`.`email`, `user`.`name` FROM `user` JOIN `account` ON account.user_id = user.id AND account.active = ? WHERE (user.id = ?)",
+			expectedArgs: []interface{}{true, 5},
+		},
+	}
+
+	for _, test := range tests {
+		sql, args, err := test.query.SQL()
+		if err != nil {
+			t.Errorf("%s: %v", test.description, err)
+			continue
+		}
+
+		if sql != test.expectedSQL {
+			t.Errorf("%s: expected %q, got %q", test.description, test.expectedSQL, sql)
+		}
+
+		if len(args) != len(test.expectedArgs) || (len(args) > 0 && !reflect.DeepEqual(args, test.expectedArgs)) {
+			t.Errorf("%s: expected args %v, got %v", test.description, test.expectedArgs, args)
+		}
+	}
+}
+
+func TestSelectSQLErrors(t *testing.T) {
+	tests := []struct {
+		description string
+		query       sqler
+	}{
+		{"placeholder count mismatch", Select[queryUser]().Where("email = ? AND id = ?", "a@b.c")},
+		{"quoted placeholder", Select[queryUser]().Where("name = '?'", "a")},
+		{"injected order by", Select[queryUser]().OrderBy("id; DROP TABLE user")},
+		{"bad order direction", Select[queryUser]().OrderBy("id SIDEWAYS")},
+		{"injected table", Select[queryUser]().From("user u")},
+		{"injected join table", Select[queryUser]().Join("account a", "a.user_id = user.id")},
+		{"negative limit", Select[queryUser]().Limit(-1)},
+		{"offset without limit", Select[queryUser]().Offset(10)},
+		{"non struct", Select[int]()},
+	}
+
+	for _, test := range tests {
+		if _, _, err := test.query.SQL(); err == nil {
+			t.Errorf("%s: expected error", test.description)
+		}
+	}
+}
+
+func TestPlaceholders(t *testing.T) {
+	tests := []struct {
+		sql      string
+		expected int
+	}{
+		{"email = ? AND id = ?", 2},
+		{"name = '?' AND id = ?", 1},
+		{`payload->'$.a?' = ?`, 1},
+		{`name = 'it''s?' OR name = "a\"?" OR ` + "`odd?` = ?", 1},
+	}
+
+	for _, test := range tests {
+		if n := placeholders(test.sql); n != test.expected {
+			t.Errorf("%s: expected %d placeholders, got %d", test.sql, test.expected, n)
+		}
+	}
+}
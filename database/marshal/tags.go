@@ -0,0 +1,126 @@
+package db
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+//tagOptions contains the comma separated options following a mysql tag's column name
+type tagOptions []string
+
+//Contains reports whether opt was set on the tag
+func (o tagOptions) Contains(opt string) bool {
+	for _, v := range o {
+		if v == opt {
+			return true
+		}
+	}
+	return false
+}
+
+//parseTag splits a mysql tag such as `mysql:"name,opt"` into its column name and options
+func parseTag(tag string) (string, tagOptions) {
+	var spl = strings.Split(tag, ",")
+	var name = strings.TrimSpace(spl[0])
+
+	var opts = make(tagOptions, 0, len(spl)-1)
+	for _, opt := range spl[1:] {
+		if opt = strings.TrimSpace(opt); opt != "" {
+			opts = append(opts, opt)
+		}
+	}
+
+	return name, opts
+}
+
+//column describes a struct field that maps to a mysql column
+type column struct {
+	Name  string
+	Index int
+	Opts  tagOptions
+}
+
+//value returns the field of struct v as it should be passed to the driver
+func (c column) value(v reflect.Value) interface{} {
+	var field = v.Field(c.Index).Interface()
+	if c.Opts.Contains(OptJSON) {
+		return jsonValue{c.Name, field}
+	}
+	return field
+}
+
+//columnsOf returns every exported field of struct type t carrying a mysql tag
+func columnsOf(t reflect.Type) []column {
+	var cols = make([]column, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		fieldInfo := t.Field(i)
+		//Unexported fields can never be set by the unmarshaler
+		if fieldInfo.PkgPath != "" {
+			continue
+		}
+
+		tag, ok := fieldInfo.Tag.Lookup("mysql")
+		if !ok {
+			continue
+		}
+
+		name, opts := parseTag(tag)
+		if name == "" || name == "-" {
+			continue
+		}
+
+		cols = append(cols, column{
+			Name:  name,
+			Index: i,
+			Opts:  opts,
+		})
+	}
+	return cols
+}
+
+//ColumnValues returns the column names and values of every mysql tagged field in struct v
+//
+//Fields tagged with the json option are wrapped so they're encoded when passed to the driver.
+func ColumnValues(v interface{}) ([]string, []interface{}, error) {
+	var val = reflect.ValueOf(v)
+	if val.Kind() == reflect.Ptr && !val.IsNil() {
+		val = val.Elem()
+	}
+
+	if val.Kind() != reflect.Struct {
+		return nil, nil, fmt.Errorf("could not translate non struct %v", reflect.TypeOf(v))
+	}
+
+	var cols = columnsOf(val.Type())
+	var names = make([]string, len(cols))
+	var vals = make([]interface{}, len(cols))
+	for i, col := range cols {
+		names[i] = col.Name
+		vals[i] = col.value(val)
+	}
+
+	return names, vals, nil
+}
+
+//tableName returns the table a model is stored in, defaulting to the snake cased struct name
+func tableName(t reflect.Type) string {
+	if tabler, ok := reflect.New(t).Interface().(Tabler); ok {
+		return tabler.TableName()
+	}
+
+	var name = []rune(t.Name())
+	var buff strings.Builder
+	for i, r := range name {
+		if unicode.IsUpper(r) {
+			//Only split words on case changes so acronyms stay together (HTTPLog -> http_log)
+			if i > 0 && (!unicode.IsUpper(name[i-1]) || (i+1 < len(name) && unicode.IsLower(name[i+1]))) {
+				buff.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		buff.WriteRune(r)
+	}
+	return buff.String()
+}
@@ -0,0 +1,174 @@
+package logger
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+//Async describes how entries are queued and flushed in the background
+//
+//Files are synced every FlushInterval instead of after every entry, so entries logged just before a crash can be lost.
+type Async struct {
+	Size          int           //Entries queued before the policy applies, 1024 by default
+	FlushInterval time.Duration //How often files are synced, 1 second by default
+	Block         bool          //Wait for room when the queue is full instead of dropping the entry
+}
+
+//WithAsync queues entries and writes them from a background goroutine
+func WithAsync(a Async) Option {
+	return func(l *Log) error {
+		if a.Size < 0 || a.FlushInterval < 0 {
+			return errors.New("async limits can't be negative")
+		}
+
+		if a.Size == 0 {
+			a.Size = 1024
+		}
+
+		if a.FlushInterval == 0 {
+			a.FlushInterval = time.Second
+		}
+
+		l.async = &a
+		return nil
+	}
+}
+
+//queue is a bounded buffer of entries drained by a single flusher goroutine
+type queue struct {
+	entries chan *Entry
+	flush   chan chan error
+	done    chan struct{}
+	stopped sync.WaitGroup
+	mu      sync.RWMutex //Held for reading by push, so stop can't close the queue during a push
+	closed  bool
+	block   bool
+	dropped uint64 //Accessed atomically
+}
+
+//startAsync stops syncing files on every write and starts the flusher, it's called once all options are applied
+func (l *Log) startAsync() {
+	for _, s := range l.allSinks() {
+		if f, ok := s.w.(*file); ok {
+			f.setSync(false)
+		}
+	}
+
+	l.queue = &queue{
+		entries: make(chan *Entry, l.async.Size),
+		flush:   make(chan chan error),
+		done:    make(chan struct{}),
+		block:   l.async.Block,
+	}
+
+	l.queue.stopped.Add(1)
+	go l.flusher(l.async.FlushInterval)
+}
+
+//push queues e, waiting for room if wait is set, it reports false if the entry was dropped
+func (q *queue) push(e *Entry, wait bool) bool {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	if q.closed {
+		atomic.AddUint64(&q.dropped, 1)
+		return false
+	}
+
+	//The flusher keeps draining until stop closes the queue, so a blocked push always gets room
+	if q.block || wait {
+		q.entries <- e
+		return true
+	}
+
+	select {
+	case q.entries <- e:
+		return true
+	default:
+	}
+
+	atomic.AddUint64(&q.dropped, 1)
+	return false
+}
+
+func (l *Log) flusher(interval time.Duration) {
+	defer l.queue.stopped.Done()
+
+	var ticker = time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case e := <-l.queue.entries:
+			l.writeSinks(e)
+		case <-ticker.C:
+			l.syncFiles()
+		case done := <-l.queue.flush:
+			done <- l.drain()
+		case <-l.queue.done:
+			l.drain()
+			return
+		}
+	}
+}
+
+//drain writes every queued entry and syncs the files
+func (l *Log) drain() error {
+	for {
+		select {
+		case e := <-l.queue.entries:
+			l.writeSinks(e)
+		default:
+			return l.syncFiles()
+		}
+	}
+}
+
+func (l *Log) syncFiles() error {
+	var err error
+	for _, s := range l.allSinks() {
+		if f, ok := s.w.(*file); ok {
+			if serr := f.Sync(); serr != nil && err == nil {
+				err = serr
+			}
+		}
+	}
+	return err
+}
+
+//Flush writes every queued entry and syncs the log files
+func (l *Log) Flush() error {
+	if l.queue == nil {
+		return nil
+	}
+
+	var done = make(chan error, 1)
+	select {
+	case l.queue.flush <- done:
+		return <-done
+	case <-l.queue.done:
+		return nil
+	}
+}
+
+//Dropped returns the number of entries discarded because the queue was full or the log was closed
+func (l *Log) Dropped() uint64 {
+	if l.queue == nil {
+		return 0
+	}
+	return atomic.LoadUint64(&l.queue.dropped)
+}
+
+//stop drains the queue and waits for the flusher to exit, entries pushed afterwards are dropped
+func (q *queue) stop() {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.done)
+	}
+	q.mu.Unlock()
+
+	q.stopped.Wait()
+}
@@ -0,0 +1,43 @@
+package logger
+
+import (
+	"io"
+	"os"
+)
+
+//ColorEnabled reports whether output to w should be coloured
+//
+//Only terminals are coloured, and never when the NO_COLOR environment variable is set or TERM is dumb.
+func ColorEnabled(w io.Writer) bool {
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return fi.Mode()&os.ModeCharDevice != 0
+}
+
+//WithColor overrides whether entries written to the log file are coloured, they never are by default
+func WithColor(on bool) Option {
+	return func(l *Log) error {
+		l.sinks[0].color = on
+		return nil
+	}
+}
+
+//SinkColor overrides whether entries written to the sink are coloured, by default only terminals are
+func SinkColor(on bool) SinkOption {
+	return func(s *Sink) error {
+		s.color = on
+		return nil
+	}
+}
@@ -0,0 +1,42 @@
+package logger
+
+import (
+	"context"
+	"os"
+	"sync"
+)
+
+type ctxKey struct{}
+
+//stderrLog is returned by FromContext when the context doesn't carry a Log
+var stderrLog struct {
+	once sync.Once
+	l    *Log
+}
+
+//NewContext returns a copy of ctx carrying l
+func NewContext(ctx context.Context, l *Log) context.Context {
+	return context.WithValue(ctx, ctxKey{}, l)
+}
+
+//FromContext returns the Log carried by ctx, or a Log writing to standard error if there isn't one
+func FromContext(ctx context.Context) *Log {
+	if l, ok := ctx.Value(ctxKey{}).(*Log); ok && l != nil {
+		return l
+	}
+
+	stderrLog.once.Do(func() {
+		//StderrSink only fails on invalid options
+		sink, _ := StderrSink()
+		var f = &file{f: os.Stderr, path: os.Stderr.Name(), noSync: true}
+		stderrLog.l = &Log{core: &core{
+			levels:  DefaultLogLevels(),
+			encoder: TextEncoder,
+			file:    f,
+			path:    f.path,
+			exit:    os.Exit,
+			sinks:   []*Sink{sink},
+		}}
+	})
+	return stderrLog.l
+}
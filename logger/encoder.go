@@ -0,0 +1,221 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+//Entry contains a single log entry before it's encoded
+type Entry struct {
+	Level  string
+	Time   time.Time
+	File   string
+	Line   int
+	Msg    string
+	Fields []Field
+
+	level *LogLevel
+	color bool //Set when the entry is written to a coloured sink
+}
+
+//Field is a key/value pair attached to an entry
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+//badKey is used for a value without a key, the same way log/slog reports it
+const badKey = "!BADKEY"
+
+//toFields pairs alternating keys and values, keys that aren't strings are formatted with fmt
+//
+//Values that are errors wrapping other errors are expanded with errorChain.
+func toFields(kv []interface{}) []Field {
+	var fields = make([]Field, 0, len(kv)/2+1)
+	for i := 0; i < len(kv); i += 2 {
+		if i+1 == len(kv) {
+			fields = append(fields, Field{badKey, kv[i]})
+			break
+		}
+
+		key, ok := kv[i].(string)
+		if !ok {
+			key = fmt.Sprint(kv[i])
+		}
+		if err, ok := kv[i+1].(error); ok && wraps(err) {
+			fields = errorChain(fields, key, err)
+			continue
+		}
+		fields = append(fields, Field{key, kv[i+1]})
+	}
+	return fields
+}
+
+//severity returns the order of the entry's level, entries at unknown levels are treated as INFO
+func (e *Entry) severity() int32 {
+	if e.level != nil {
+		return e.level.severity
+	}
+
+	if sev, ok := severity[e.Level]; ok {
+		return sev
+	}
+	return severity[INFO]
+}
+
+//Caller returns the file and line the entry was logged from, or an empty string if unknown
+func (e *Entry) Caller() string {
+	if e.File == "" {
+		return ""
+	}
+	return e.File + ":" + strconv.Itoa(e.Line)
+}
+
+//Encoder function converts an entry to a line of output
+type Encoder func(*Entry) []byte
+
+//TextEncoder writes entries as `[LEVEL] 2006/01/02 15:04:05 file.go 12: [msg] key=value`
+//
+//The level is coloured when writing to a terminal.
+func TextEncoder(e *Entry) []byte {
+	var buff bytes.Buffer
+
+	if e.level != nil {
+		buff.WriteString(e.level.TextPrefix(e.color))
+	} else {
+		buff.WriteString("[" + e.Level + "] ")
+	}
+
+	buff.WriteString(e.Time.Format("2006/01/02 15:04:05 "))
+	if e.File != "" {
+		buff.WriteString(fmt.Sprintf("%s %d: ", e.File, e.Line))
+	}
+
+	buff.WriteString("[" + e.Msg + "]")
+	for _, f := range e.Fields {
+		buff.WriteByte(' ')
+		writeKV(&buff, f.Key, f.Value)
+	}
+
+	buff.WriteByte('\n')
+	return buff.Bytes()
+}
+
+//JSONEncoder writes entries as one JSON object per line containing level, ts, caller, msg, and each field
+func JSONEncoder(e *Entry) []byte {
+	var buff bytes.Buffer
+
+	buff.WriteString(`{"level":`)
+	writeJSON(&buff, e.Level)
+	buff.WriteString(`,"ts":`)
+	writeJSON(&buff, e.Time.Format(time.RFC3339Nano))
+	if e.File != "" {
+		buff.WriteString(`,"caller":`)
+		writeJSON(&buff, e.Caller())
+	}
+	buff.WriteString(`,"msg":`)
+	writeJSON(&buff, e.Msg)
+
+	for _, f := range e.Fields {
+		buff.WriteByte(',')
+		writeJSON(&buff, f.Key)
+		buff.WriteByte(':')
+		writeJSON(&buff, fieldValue(f.Value))
+	}
+
+	buff.WriteString("}\n")
+	return buff.Bytes()
+}
+
+//LogfmtEncoder writes entries as `level=INFO ts=... caller=file.go:12 msg="..." key=value`
+func LogfmtEncoder(e *Entry) []byte {
+	var buff bytes.Buffer
+
+	writeKV(&buff, "level", e.Level)
+	buff.WriteByte(' ')
+	writeKV(&buff, "ts", e.Time.Format(time.RFC3339Nano))
+	if e.File != "" {
+		buff.WriteByte(' ')
+		writeKV(&buff, "caller", e.Caller())
+	}
+	buff.WriteByte(' ')
+	writeKV(&buff, "msg", e.Msg)
+
+	for _, f := range e.Fields {
+		buff.WriteByte(' ')
+		writeKV(&buff, f.Key, f.Value)
+	}
+
+	buff.WriteByte('\n')
+	return buff.Bytes()
+}
+
+//message formats log arguments the way fmt.Println would, without the newline
+func message(p []interface{}) string {
+	var msg = fmt.Sprintln(p...)
+	return msg[:len(msg)-1]
+}
+
+//fieldValue converts values that don't encode to JSON on their own
+func fieldValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case json.Marshaler:
+		return val
+	case error:
+		return val.Error()
+	case fmt.Stringer:
+		return val.String()
+	case time.Duration:
+		return val.String()
+	}
+	return v
+}
+
+func writeJSON(buff *bytes.Buffer, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		b, _ = json.Marshal(fmt.Sprint(v))
+	}
+	buff.Write(b)
+}
+
+//writeKV writes key=value, quoting values that contain spaces, quotes, equals signs, or control characters
+func writeKV(buff *bytes.Buffer, key string, v interface{}) {
+	buff.WriteString(key)
+	buff.WriteByte('=')
+
+	var s string
+	switch val := fieldValue(v).(type) {
+	case string:
+		s = val
+	case json.Marshaler:
+		b, err := val.MarshalJSON()
+		if err != nil {
+			s = fmt.Sprint(v)
+		} else {
+			s = string(b)
+		}
+	default:
+		s = fmt.Sprint(val)
+	}
+
+	if needsQuote(s) {
+		s = strconv.Quote(s)
+	}
+	buff.WriteString(s)
+}
+
+func needsQuote(s string) bool {
+	if s == "" {
+		return true
+	}
+
+	return strings.IndexFunc(s, func(r rune) bool {
+		return r <= ' ' || r == '=' || r == '"' || unicode.IsControl(r) || unicode.IsSpace(r)
+	}) >= 0
+}
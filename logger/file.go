@@ -0,0 +1,129 @@
+package logger
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+//file is a log file that can be rotated and reopened while it's being written to
+type file struct {
+	mu     sync.Mutex
+	f      *os.File
+	path   string
+	noSync bool          //Set when a flusher syncs the file instead of every write
+	header func() []byte //Written at the start of every new file
+
+	rotation *Rotation
+	size     int64     //Bytes written to the current file
+	next     time.Time //Next periodic rotation
+	bg       sync.WaitGroup
+	bgMu     sync.Mutex //Serializes compressing and pruning rotated files
+}
+
+//newFile opens path for appending
+func newFile(path string) (*file, error) {
+	f, err := openFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return &file{
+		f:    f,
+		path: path,
+		size: fi.Size(),
+	}, nil
+}
+
+//Write appends b to the file, rotating it first if needed
+func (f *file) Write(b []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.rotation != nil {
+		if now := time.Now(); f.rotation.due(f.size, len(b), now, f.next) {
+			if err := f.rotate(now); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	if f.size == 0 && f.header != nil {
+		hn, err := f.f.Write(f.header())
+		f.size += int64(hn)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.f.Write(b)
+	f.size += int64(n)
+	if err != nil {
+		return n, err
+	}
+
+	if !f.noSync {
+		err = f.f.Sync()
+	}
+	return n, err
+}
+
+//Sync commits the file's contents to disk
+func (f *file) Sync() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.f.Sync()
+}
+
+func (f *file) setHeader(header func() []byte) {
+	f.mu.Lock()
+	f.header = header
+	f.mu.Unlock()
+}
+
+func (f *file) setSync(sync bool) {
+	f.mu.Lock()
+	f.noSync = !sync
+	f.mu.Unlock()
+}
+
+//Reopen swaps the file handle for a newly opened one at the same path
+func (f *file) Reopen() error {
+	nf, err := newFile(f.path)
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	var old = f.f
+	f.f = nf.f
+	f.size = nf.size
+	f.mu.Unlock()
+
+	return old.Close()
+}
+
+//Stat returns the current file's info
+func (f *file) Stat() (os.FileInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.f.Stat()
+}
+
+//Close closes the file, waiting for rotated files to finish compressing
+func (f *file) Close() error {
+	f.mu.Lock()
+	var err = f.f.Close()
+	f.mu.Unlock()
+
+	f.bg.Wait()
+	return err
+}
@@ -3,7 +3,6 @@ package logfmt
 import (
 	"bytes"
 	"strings"
-	"time"
 )
 
 //ELFFV = Extended Log File Format Version
@@ -20,62 +19,52 @@ type Directive struct {
 	Remark    string
 }
 
-//NewDirective ...
+//DateTime is the layout of the #Date, #Start-Date and #End-Date directives
+const DateTime = "2006-01-02 15:04:05"
+
+//NewDirective returns the directives of a log file, #Date is set by ELFWriter when each file is started
 func NewDirective(software, version, remark string, fields ...string) *Directive {
 	return &Directive{
 		Version:  version,
 		Software: software,
-		Date:     time.Now().Format(time.RFC1123),
 		Fields:   fields,
 		Remark:   remark,
 	}
 }
 
-//ToBytes converts directive to
+//ToBytes converts directive to the header lines of a log file, each terminated by LF
 func (d *Directive) ToBytes() []byte {
-	var buff = bytes.NewBuffer(nil)
-
 	if len(d.Fields) == 0 {
 		panic("logfile: directive requires at least one field")
 	}
 
-	if !emptyString(d.Version) {
-		buff.Write([]byte("#Version: " + d.Version))
-		buff.Write(LF)
-	}
-
-	if !emptyString(d.Software) {
-		buff.Write([]byte("#Software: " + d.Software))
-		buff.Write(LF)
-	}
-
-	if !emptyString(d.Date) {
-		buff.Write([]byte("#Date: " + d.Date))
-		buff.Write(LF)
-	}
+	return d.bytes(LF)
+}
 
-	if !emptyString(d.StartDate) {
-		buff.Write([]byte("#Start-Date: " + d.StartDate))
-		buff.Write(LF)
-	}
-	if !emptyString(d.EndDate) {
-		buff.Write([]byte("#End-Date: " + d.EndDate))
-		buff.Write(LF)
-	}
+//bytes writes each directive that is set on its own line, #Fields last
+func (d *Directive) bytes(eol EOL) []byte {
+	var buff = bytes.NewBuffer(nil)
 
-	if !emptyString(d.Remark) {
-		buff.Write([]byte("#Remark: " + d.Remark))
-		buff.Write(LF)
+	var directives = []struct {
+		name, value string
+	}{
+		{"Version", d.Version},
+		{"Software", d.Software},
+		{"Date", d.Date},
+		{"Start-Date", d.StartDate},
+		{"End-Date", d.EndDate},
+		{"Remark", d.Remark},
 	}
 
-	buff.Write([]byte("#Fields: "))
-	for _, field := range d.Fields {
-		buff.Write([]byte(field))
-		buff.Write(SPACE)
+	for _, dir := range directives {
+		if !emptyString(dir.value) {
+			buff.WriteString("#" + dir.name + ": " + dir.value)
+			buff.Write(eol)
+		}
 	}
 
-	buff.Write(LF)
-	buff.Write(LF)
+	buff.WriteString("#Fields: " + strings.Join(d.Fields, " "))
+	buff.Write(eol)
 	return buff.Bytes()
 }
 
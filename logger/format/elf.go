@@ -0,0 +1,150 @@
+package logfmt
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+//ELFWriter writes entries to a W3C Extended Log File, https://www.w3.org/TR/WD-logfile
+//
+//The directives are written at the start of the file, and entries must have one field per identifier in #Fields.
+type ELFWriter struct {
+	mu        sync.Mutex
+	w         io.Writer
+	directive Directive
+	eol       EOL
+	header    bool //Set once the directives have been written to w
+}
+
+//headerSetter is implemented by writers that start new files, such as a rotating logger.Log
+type headerSetter interface {
+	SetHeader(func() []byte)
+}
+
+//NewELFWriter returns a writer of entries to w terminated by eol, which must be CRLF or LF
+//
+//If w is a logger.Log the directives are written at the start of every file it opens, including after rotation,
+//otherwise they're written once before the first entry.
+func NewELFWriter(w io.Writer, d *Directive, eol EOL) (*ELFWriter, error) {
+	if w == nil || d == nil {
+		return nil, errors.New("writer and directive must be provided")
+	}
+
+	if string(eol) != string(CRLF) && string(eol) != string(LF) {
+		return nil, errors.New("line terminator must be CRLF or LF")
+	}
+
+	if len(d.Fields) == 0 {
+		return nil, errors.New("directive requires at least one field")
+	}
+
+	for _, f := range d.Fields {
+		if !ValidIdentifier(f) {
+			return nil, fmt.Errorf("invalid field identifier %q", f)
+		}
+	}
+
+	var ew = &ELFWriter{
+		w:         w,
+		directive: *d,
+		eol:       eol,
+	}
+
+	if hs, ok := w.(headerSetter); ok {
+		hs.SetHeader(ew.Header)
+		ew.header = true
+	}
+
+	return ew, nil
+}
+
+//Header returns the directives, with #Date set to now in UTC when the directive doesn't specify one
+func (ew *ELFWriter) Header() []byte {
+	var d = ew.directive
+	if d.Version == "" {
+		d.Version = ELFFV
+	}
+
+	if d.Date == "" {
+		d.Date = time.Now().UTC().Format(DateTime)
+	}
+
+	return d.bytes(ew.eol)
+}
+
+//Write writes e as a single line with its fields in the order of #Fields
+//
+//It's an error if e doesn't have exactly one field named after each identifier in #Fields.
+func (ew *ELFWriter) Write(e *Entry) error {
+	if len(e.fields) != len(ew.directive.Fields) {
+		return fmt.Errorf("entry has %d fields, #Fields declares %d", len(e.fields), len(ew.directive.Fields))
+	}
+
+	var ordered = &Entry{fields: make([]Field, len(ew.directive.Fields))}
+	for _, f := range e.fields {
+		var i = ew.index(f.Name)
+		if i < 0 {
+			return fmt.Errorf("field %q isn't declared in #Fields", f.Name)
+		}
+
+		if ordered.fields[i].Name != "" {
+			return fmt.Errorf("field %q is repeated", f.Name)
+		}
+		ordered.fields[i] = f
+	}
+
+	var b = append(ELFEncoder(ordered), ew.eol...)
+
+	ew.mu.Lock()
+	defer ew.mu.Unlock()
+
+	if !ew.header {
+		if _, err := ew.w.Write(ew.Header()); err != nil {
+			return err
+		}
+		ew.header = true
+	}
+
+	_, err := ew.w.Write(b)
+	return err
+}
+
+//index returns the position of the identifier in #Fields, or -1
+func (ew *ELFWriter) index(name string) int {
+	for i, f := range ew.directive.Fields {
+		if f == name {
+			return i
+		}
+	}
+	return -1
+}
+
+//quote returns a value as a W3C field, missing values are written as a dash
+//
+//Values containing whitespace or quotes are quoted with inner quotes doubled, and
+//control characters are replaced with spaces so an entry never spans lines.
+func quote(s string) string {
+	if s == "" {
+		return "-"
+	}
+
+	if s != "-" && !strings.ContainsAny(s, " \t\r\n\"") && strings.IndexFunc(s, isControl) < 0 {
+		return s
+	}
+
+	s = strings.Map(func(r rune) rune {
+		if isControl(r) {
+			return ' '
+		}
+		return r
+	}, s)
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+func isControl(r rune) bool {
+	return r < ' ' || r == 0x7f
+}
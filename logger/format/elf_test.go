@@ -0,0 +1,250 @@
+package logfmt
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/random9s/cinder/logger"
+	"github.com/random9s/cinder/logger/reader"
+)
+
+func TestELFWriter(t *testing.T) {
+	var buff bytes.Buffer
+	var d = &Directive{Software: "cinder", Date: "2018-01-02 03:04:05", Fields: []string{"cs-method", "cs-uri", "sc-status", "x-comment"}}
+
+	ew, err := NewELFWriter(&buff, d, CRLF)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var entries = []*Entry{
+		NewEntry().Append(Method("GET"), URI("/a b"), Status(200), Comment(`said "hi"`).As("x-comment")),
+		NewEntry().Append(Method("POST"), URI("/"), Status(500), Comment("").As("x-comment")),
+	}
+
+	for _, e := range entries {
+		if err := ew.Write(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var expected = "#Version: 1.0\r\n#Software: cinder\r\n#Date: 2018-01-02 03:04:05\r\n#Fields: cs-method cs-uri sc-status x-comment\r\n" +
+		"GET \"/a b\" 200 \"said \"\"hi\"\"\"\r\n" +
+		"POST / 500 -\r\n"
+	if buff.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buff.String())
+	}
+
+	if err := ew.Write(NewEntry().Append(Method("GET"))); err == nil {
+		t.Error("expected error for an entry missing fields")
+	}
+
+	if err := ew.Write(NewEntry().Append(Method("GET"), URI("/"), Status(200), Comment(""))); err == nil {
+		t.Error("expected error for an undeclared field")
+	}
+
+	if _, err := NewELFWriter(&buff, d, TAB); err == nil {
+		t.Error("expected error for an invalid line terminator")
+	}
+}
+
+func TestELFWriterRotation(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "access.log")
+
+	l, err := logger.New(path, logger.WithRotation(logger.Rotation{MaxSize: 300}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ew, err := NewELFWriter(l, NewDirective("cinder", ELFFV, "", "cs-uri", "time-taken"), LF)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 20; i++ {
+		if err := ew.Write(NewEntry().Append(URI("/index.html"), TimeTaken(time.Second))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := reader.Files(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(files) < 2 {
+		t.Fatalf("expected rotated files, got %v", files)
+	}
+
+	for _, file := range files {
+		var b = readAll(t, file)
+		if !strings.HasPrefix(b, "#Version: 1.0\n#Software: cinder\n#Date: ") || strings.Count(b, "#Fields: cs-uri time-taken\n") != 1 {
+			t.Errorf("expected one header at the start of %s, got %q", file, b)
+		}
+
+		var lines = strings.Split(b, "\n")
+		if date, err := time.Parse(DateTime, strings.TrimPrefix(lines[2], "#Date: ")); err != nil || time.Since(date) > time.Minute {
+			t.Errorf("expected the date %s was started in UTC, got %q", file, lines[2])
+		}
+	}
+}
+
+func readAll(t *testing.T, path string) string {
+	f, err := reader.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	b, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestParser(t *testing.T) {
+	var log = "#Version: 1.0\r\n" +
+		"#Date: 12-Jan-1996 00:00:00\r\n" +
+		"#Fields: cs-method cs-uri sc-status time-taken\r\n" +
+		"GET \"/a b\" 200 1.5\r\n" +
+		"\r\n" +
+		"POST / 500 oops\r\n" +
+		"#Fields: cs-uri sc-bytes x-comment\r\n" +
+		"/ 512 \"said \"\"hi\"\"\"\r\n" +
+		"/ - -\r\n"
+
+	var p = NewParser(strings.NewReader(log))
+
+	rec, err := p.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rec.Directive.Version != "1.0" || rec.Directive.Date != "12-Jan-1996 00:00:00" {
+		t.Errorf("unexpected directive %+v", rec.Directive)
+	}
+
+	var expected = []interface{}{"GET", "/a b", 200, 1500 * time.Millisecond}
+	for i, v := range expected {
+		if rec.Values[i] != v {
+			t.Errorf("expected %s %#v, got %#v", rec.Fields[i], v, rec.Values[i])
+		}
+	}
+
+	if _, err := p.Next(); err == nil || !strings.Contains(err.Error(), "line 6: time-taken") {
+		t.Errorf("expected error for invalid time-taken, got %v", err)
+	}
+
+	//Parsing continues after an error, with the new field list
+	var out bytes.Buffer
+	var enc = json.NewEncoder(&out)
+	for rec, err := range p.Records() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		enc.Encode(rec)
+	}
+
+	var lines = "{\"cs-uri\":\"/\",\"sc-bytes\":512,\"x-comment\":\"said \\\"hi\\\"\"}\n{\"cs-uri\":\"/\",\"sc-bytes\":null,\"x-comment\":null}\n"
+	if out.String() != lines {
+		t.Errorf("expected %q, got %q", lines, out.String())
+	}
+}
+
+func TestParserLongLine(t *testing.T) {
+	var agent = strings.Repeat("a", 100*1024)
+	var log = "#Fields: cs-uri cs(User-Agent)\n/ " + agent + "\n/ " + strings.Repeat("b", maxLine) + "\n"
+
+	var p = NewParser(strings.NewReader(log))
+	rec, err := p.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v, _ := rec.Get("cs(User-Agent)"); v != agent {
+		t.Errorf("expected the %d byte user agent, got %d bytes", len(agent), len(fmt.Sprint(v)))
+	}
+
+	if _, err := p.Next(); err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("expected error for line past the limit, got %v", err)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	var buff bytes.Buffer
+	ew, err := NewELFWriter(&buff, NewDirective("cinder", ELFFV, "", "cs-method", "cs-uri", "sc-status", "time-taken"), LF)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ew.Write(NewEntry().Append(Method("GET"), URI(`/q="x y"`), Status(404), TimeTaken(250*time.Millisecond))); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := ToJSONLines(&out, &buff); err != nil {
+		t.Fatal(err)
+	}
+
+	var expected = `{"cs-method":"GET","cs-uri":"/q=\"x y\"","sc-status":404,"time-taken":0.25}` + "\n"
+	if out.String() != expected {
+		t.Errorf("expected %q, got %q", expected, out.String())
+	}
+}
+
+func TestEncoders(t *testing.T) {
+	var e = NewEntry().Append(
+		NewField("date", time.Date(2018, 1, 2, 3, 4, 5, 0, time.UTC)),
+		IP("10.0.0.1"),
+		Method("DELETE"),
+		URI("/a b"),
+		Status(404),
+		TimeTaken(1500*time.Millisecond),
+		NewField("x-note", nil),
+	)
+
+	var elf = `2018-01-02 10.0.0.1 DELETE "/a b" 404 1.5 -`
+	if out := string(e.ToBytes()); out != elf {
+		t.Errorf("expected %q, got %q", elf, out)
+	}
+
+	var js = `{"date":"2018-01-02","c-ip":"10.0.0.1","cs-method":"DELETE","cs-uri":"/a b","sc-status":404,"time-taken":1.5,"x-note":null}`
+	if out := string(e.Encode(JSONEncoder)); out != js {
+		t.Errorf("expected %q, got %q", js, out)
+	}
+
+	var kv = `date=2018-01-02 c-ip=10.0.0.1 cs-method=DELETE cs-uri="/a b" sc-status=404 time-taken=1.5 x-note=""`
+	if out := string(e.Encode(LogfmtEncoder)); out != kv {
+		t.Errorf("expected %q, got %q", kv, out)
+	}
+
+	if out := string(e.ColorBytes()); !strings.Contains(out, "\x1b[31m404\x1b[0m") || !strings.Contains(out, "\x1b[31mDELETE\x1b[0m") {
+		t.Errorf("expected red status and method, got %q", out)
+	}
+
+	//ELFWriter puts fields in the declared order
+	var buff bytes.Buffer
+	ew, err := NewELFWriter(&buff, &Directive{Date: "-", Fields: []string{"sc-status", "cs-method"}}, LF)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ew.Write(NewEntry().Append(Method("GET"), Status(200))); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasSuffix(buff.String(), "\n200 GET\n") {
+		t.Errorf("expected fields in declared order, got %q", buff.String())
+	}
+}
@@ -0,0 +1,81 @@
+package logfmt
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+//Encoder renders an entry as a single line without a terminator
+type Encoder func(*Entry) []byte
+
+//ELFEncoder writes the values of the fields in order separated by spaces, quoted the way ELFWriter does
+func ELFEncoder(e *Entry) []byte {
+	var buff bytes.Buffer
+	for i, f := range e.fields {
+		if i > 0 {
+			buff.Write(SPACE)
+		}
+		buff.WriteString(quote(f.String()))
+	}
+	return buff.Bytes()
+}
+
+//JSONEncoder writes an object keyed by field name, durations are in seconds
+func JSONEncoder(e *Entry) []byte {
+	var buff bytes.Buffer
+	buff.WriteByte('{')
+
+	for i, f := range e.fields {
+		if i > 0 {
+			buff.WriteByte(',')
+		}
+
+		writeJSON(&buff, f.Name)
+		buff.WriteByte(':')
+
+		switch v := f.Value.(type) {
+		case time.Duration:
+			writeJSON(&buff, v.Seconds())
+		case time.Time, error:
+			writeJSON(&buff, f.String())
+		default:
+			writeJSON(&buff, v)
+		}
+	}
+
+	buff.WriteByte('}')
+	return buff.Bytes()
+}
+
+//LogfmtEncoder writes name=value pairs, quoting values that contain spaces, quotes, equals signs, or control characters
+func LogfmtEncoder(e *Entry) []byte {
+	var buff bytes.Buffer
+	for i, f := range e.fields {
+		if i > 0 {
+			buff.WriteByte(' ')
+		}
+
+		var s = f.String()
+		if s == "" || strings.IndexFunc(s, func(r rune) bool {
+			return r <= ' ' || r == '=' || r == '"' || unicode.IsControl(r) || unicode.IsSpace(r)
+		}) >= 0 {
+			s = strconv.Quote(s)
+		}
+
+		buff.WriteString(f.Name + "=" + s)
+	}
+	return buff.Bytes()
+}
+
+func writeJSON(buff *bytes.Buffer, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		b, _ = json.Marshal(fmt.Sprint(v))
+	}
+	buff.Write(b)
+}
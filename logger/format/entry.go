@@ -35,15 +35,34 @@ func (e *Entry) Append(fields ...Field) *Entry {
 	return e
 }
 
-//ToBytes converts and entry to a byte slice
+//Fields returns the entry's fields in the order they were appended
+func (e *Entry) Fields() []Field {
+	return e.fields
+}
+
+//Encode renders the entry with enc
+func (e *Entry) Encode(enc Encoder) []byte {
+	return enc(e)
+}
+
+//ToBytes converts an entry to its Extended Log File line without a terminator
 func (e *Entry) ToBytes() []byte {
+	return ELFEncoder(e)
+}
+
+//ColorBytes converts an entry to its Extended Log File line, colouring fields such as Status and Method for a terminal
+func (e *Entry) ColorBytes() []byte {
 	var buff = bytes.NewBuffer(nil)
+	for i, f := range e.fields {
+		if i > 0 {
+			buff.Write(SPACE)
+		}
 
-	for _, field := range e.fields {
-		var fBytes = field.data
-		buff.Write(fBytes)
-		buff.Write(SPACE)
+		if s := f.String(); quote(s) != s {
+			buff.WriteString(quote(s))
+		} else {
+			buff.WriteString(f.colored())
+		}
 	}
-
 	return buff.Bytes()
 }
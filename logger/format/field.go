@@ -2,6 +2,7 @@ package logfmt
 
 import (
 	"fmt"
+	"net"
 	"net/http"
 	"strconv"
 	"time"
@@ -10,98 +11,143 @@ import (
 )
 
 //Field contains part of an entry
+//
+//Value is an int, time.Duration, time.Time, string, net.IP, or nil for a missing value, and is formatted by the encoder.
 type Field struct {
-	data []byte
+	Name  string
+	Value interface{}
 }
 
-//NewField returns a newly initialized field
-func NewField(data []byte) Field {
+//NewField returns a field with the W3C identifier name
+func NewField(name string, value interface{}) Field {
 	return Field{
-		data: data,
+		Name:  name,
+		Value: value,
 	}
 }
 
+//As returns the field with a different identifier, such as IP(addr).As("s-ip")
+func (f Field) As(name string) Field {
+	f.Name = name
+	return f
+}
+
 //ErrorMsg contains an error message
 func ErrorMsg(err error) Field {
-	var e = []byte(err.Error())
-	return NewField(e)
+	return NewField("x-error", err.Error())
 }
 
 //TimeTaken for transaction to complete in seconds
 func TimeTaken(dur time.Duration) Field {
-	var durBytes = []byte(dur.String())
-	return NewField(durBytes)
+	return NewField("time-taken", dur)
 }
 
 //Bytes transferred
 func Bytes(n int) Field {
-	var nStr = strconv.Itoa(n)
-	var nBytes = []byte(nStr)
-	return NewField(nBytes)
+	return NewField("bytes", n)
 }
 
-//IP address and port
+//IP address of the client
 func IP(ipaddr string) Field {
-	var ipBytes = []byte(ipaddr)
-	return NewField(ipBytes)
+	return parseIP(ipaddr)
 }
 
-//DNS name
+//DNS name of the client
 func DNS(dns string) Field {
-	var dnsBytes = []byte(dns)
-	return NewField(dnsBytes)
+	return NewField("c-dns", dns)
 }
 
-//Status code
+//Status code sent to the client
 func Status(code int) Field {
-	var f = color.New(color.FgGreen).SprintFunc()
-	if code != http.StatusOK {
-		f = color.New(color.FgRed).SprintFunc()
-	}
-
-	codeStr := fmt.Sprintf("%s", f(code))
-	var statusBytes = []byte(codeStr)
-	return NewField(statusBytes)
+	return NewField("sc-status", code)
 }
 
 //Comment returned with status code
 func Comment(c string) Field {
-	var cBytes = []byte(c)
-	return NewField(cBytes)
+	return NewField("sc-comment", c)
 }
 
-//Method ...
+//Method requested by the client
 func Method(method string) Field {
-	var f = color.New(color.FgGreen).SprintFunc()
-
-	switch method {
-	case http.MethodPost:
-		f = color.New(color.FgBlue).SprintFunc()
-	case http.MethodPut, http.MethodPatch:
-		f = color.New(color.FgYellow).SprintFunc()
-	case http.MethodDelete:
-		f = color.New(color.FgRed).SprintFunc()
-	}
-
-	methodStr := fmt.Sprintf("%s", f(method))
-	var mBytes = []byte(methodStr)
-	return NewField(mBytes)
+	return NewField("cs-method", method)
 }
 
-//URI ...
+//URI requested by the client
 func URI(uri string) Field {
-	var uBytes = []byte(uri)
-	return NewField(uBytes)
+	return NewField("cs-uri", uri)
 }
 
 //URIStem stem portion of URI (omit query)
 func URIStem(uri string) Field {
-	var uBytes = []byte(uri)
-	return NewField(uBytes)
+	return NewField("cs-uri-stem", uri)
 }
 
 //URIQuery query portion of URI
 func URIQuery(uri string) Field {
-	var uBytes = []byte(uri)
-	return NewField(uBytes)
+	return NewField("cs-uri-query", uri)
+}
+
+//String formats the value the way it's written to an Extended Log File, before quoting
+//
+//Durations are in seconds, date and time fields are UTC, and missing values are empty.
+func (f Field) String() string {
+	switch v := f.Value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case time.Duration:
+		return strconv.FormatFloat(v.Seconds(), 'f', -1, 64)
+	case time.Time:
+		switch f.Name {
+		case "date":
+			return v.UTC().Format("2006-01-02")
+		case "time":
+			return v.UTC().Format("15:04:05")
+		}
+		return v.UTC().Format(time.RFC3339Nano)
+	case net.IP:
+		return v.String()
+	case error:
+		return v.Error()
+	}
+	return fmt.Sprint(f.Value)
+}
+
+//colored returns the field's value in a colour depending on the field, green for 200 and red otherwise for statuses
+func (f Field) colored() string {
+	var s = f.String()
+
+	var c color.Attribute
+	switch {
+	case isStatus(f.Name):
+		c = color.FgGreen
+		if code, ok := f.Value.(int); ok && code != http.StatusOK {
+			c = color.FgRed
+		}
+	case f.Name == "cs-method":
+		switch s {
+		case http.MethodPost:
+			c = color.FgBlue
+		case http.MethodPut, http.MethodPatch:
+			c = color.FgYellow
+		case http.MethodDelete:
+			c = color.FgRed
+		default:
+			c = color.FgGreen
+		}
+	default:
+		return s
+	}
+
+	var col = color.New(c)
+	//Whether colour is used is decided by the caller, not by fatih/color's check of stdout
+	col.EnableColor()
+	return col.Sprint(s)
+}
+
+func isStatus(name string) bool {
+	return name == "sc-status" || name == "status"
 }
@@ -0,0 +1,239 @@
+package logfmt
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+)
+
+//Prefix says which side of a transfer a field describes
+type Prefix string
+
+//Field prefixes
+const (
+	Client         Prefix = "c"  //Client
+	Server         Prefix = "s"  //Server
+	Remote         Prefix = "r"  //Remote, the server a proxy forwards to
+	ClientToServer Prefix = "cs" //Sent by the client to the server
+	ServerToClient Prefix = "sc" //Sent by the server to the client
+	ServerToRemote Prefix = "sr" //Sent by the server to the remote server
+	RemoteToServer Prefix = "rs" //Sent by the remote server to the server
+	App            Prefix = "x"  //Application specific
+)
+
+//Field returns the field id with the prefix, Server.Field("ip", addr) is s-ip
+func (p Prefix) Field(id string, value interface{}) Field {
+	return NewField(string(p)+"-"+id, value)
+}
+
+//Header returns a header field, ClientToServer.Header("User-Agent", ua) is cs(User-Agent)
+func (p Prefix) Header(name, value string) Field {
+	return NewField(string(p)+"("+name+")", value)
+}
+
+//identifier matches every W3C identifier and the common IIS extensions
+var identifier = regexp.MustCompile(`^(?:date|time|time-taken|bytes|cached|` +
+	`(?:c|s|r|cs|sc|sr|rs)-(?:ip|dns|status|comment|method|uri|uri-stem|uri-query|` +
+	`username|sitename|computername|port|host|version|bytes|substatus|win32-status)|` +
+	`(?:c|s|r|cs|sc|sr|rs)\([!#$%&'*+.^_` + "`" + `|~0-9A-Za-z-]+\)|` +
+	`x-[!-~]+)$`)
+
+//ValidIdentifier reports whether id can be declared in #Fields
+func ValidIdentifier(id string) bool {
+	return identifier.MatchString(id)
+}
+
+//Response is a response captured while it was written, such as accesslog.ResponseWriter
+type Response interface {
+	Header() http.Header
+	Status() int
+	Size() int
+}
+
+//Date the transaction completed, in UTC
+func Date(t time.Time) Field {
+	return NewField("date", t)
+}
+
+//Time the transaction completed, in UTC
+func Time(t time.Time) Field {
+	return NewField("time", t)
+}
+
+//Cached is 1 if the response was served from a cache, 0 otherwise
+func Cached(cached bool) Field {
+	if cached {
+		return NewField("cached", 1)
+	}
+	return NewField("cached", 0)
+}
+
+//Custom returns an application specific field, Custom("region", v) is x-region
+func Custom(name string, value interface{}) Field {
+	return App.Field(name, value)
+}
+
+//ClientIP is the address r was sent from, c-ip
+func ClientIP(r *http.Request) Field {
+	return IP(host(r.RemoteAddr))
+}
+
+//ServerIP is the address r was received on, s-ip
+func ServerIP(r *http.Request) Field {
+	return parseIP(host(localAddr(r))).As("s-ip")
+}
+
+//ServerPort is the port r was received on, s-port
+func ServerPort(r *http.Request) Field {
+	_, port, err := net.SplitHostPort(localAddr(r))
+	if err != nil {
+		return Server.Field("port", nil)
+	}
+
+	n, err := strconv.Atoi(port)
+	if err != nil {
+		return Server.Field("port", port)
+	}
+	return Server.Field("port", n)
+}
+
+//SiteName of the server, s-sitename
+func SiteName(name string) Field {
+	return Server.Field("sitename", name)
+}
+
+var hostname struct {
+	once sync.Once
+	name string
+}
+
+//ComputerName is the host name of the machine logging, s-computername
+func ComputerName() Field {
+	hostname.once.Do(func() {
+		hostname.name, _ = os.Hostname()
+	})
+	return Server.Field("computername", hostname.name)
+}
+
+//Username sent with basic auth or in the URL of r, cs-username
+func Username(r *http.Request) Field {
+	if user, _, ok := r.BasicAuth(); ok {
+		return ClientToServer.Field("username", user)
+	}
+
+	if r.URL.User != nil {
+		return ClientToServer.Field("username", r.URL.User.Username())
+	}
+	return ClientToServer.Field("username", nil)
+}
+
+//RequestMethod of r, cs-method
+func RequestMethod(r *http.Request) Field {
+	return Method(r.Method)
+}
+
+//RequestURI of r including the query, cs-uri
+func RequestURI(r *http.Request) Field {
+	return URI(r.URL.RequestURI())
+}
+
+//RequestURIStem is the path of r, cs-uri-stem
+func RequestURIStem(r *http.Request) Field {
+	return URIStem(r.URL.Path)
+}
+
+//RequestURIQuery is the query of r, cs-uri-query
+func RequestURIQuery(r *http.Request) Field {
+	return URIQuery(r.URL.RawQuery)
+}
+
+//Host requested by r, cs-host
+func Host(r *http.Request) Field {
+	return ClientToServer.Field("host", r.Host)
+}
+
+//Version is the protocol of r such as HTTP/1.1, cs-version
+func Version(r *http.Request) Field {
+	return ClientToServer.Field("version", r.Proto)
+}
+
+//RequestBytes is the length of r's body, cs-bytes, missing if it isn't known
+func RequestBytes(r *http.Request) Field {
+	if r.ContentLength < 0 {
+		return ClientToServer.Field("bytes", nil)
+	}
+	return ClientToServer.Field("bytes", int(r.ContentLength))
+}
+
+//RequestHeader is a header of r, cs(name)
+func RequestHeader(r *http.Request, name string) Field {
+	return ClientToServer.Header(name, r.Header.Get(name))
+}
+
+//UserAgent of r, cs(User-Agent)
+func UserAgent(r *http.Request) Field {
+	return RequestHeader(r, "User-Agent")
+}
+
+//Referer of r, cs(Referer)
+func Referer(r *http.Request) Field {
+	return RequestHeader(r, "Referer")
+}
+
+//ResponseStatus of resp, sc-status
+func ResponseStatus(resp Response) Field {
+	return Status(resp.Status())
+}
+
+//ResponseBytes is the size of resp's body, sc-bytes
+func ResponseBytes(resp Response) Field {
+	return ServerToClient.Field("bytes", resp.Size())
+}
+
+//ResponseHeader is a header of resp, sc(name)
+func ResponseHeader(resp Response, name string) Field {
+	return ServerToClient.Header(name, resp.Header().Get(name))
+}
+
+//SubStatus refines the status, such as 404.2 in IIS, sc-substatus
+func SubStatus(code int) Field {
+	return ServerToClient.Field("substatus", code)
+}
+
+//Win32Status is the Windows error code of the transaction, sc-win32-status
+func Win32Status(code int) Field {
+	return ServerToClient.Field("win32-status", code)
+}
+
+//localAddr returns the address r was received on, empty if the server didn't record it
+func localAddr(r *http.Request) string {
+	if addr, ok := r.Context().Value(http.LocalAddrContextKey).(net.Addr); ok {
+		return addr.String()
+	}
+	return ""
+}
+
+//host returns addr without its port
+func host(addr string) string {
+	h, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return h
+}
+
+//parseIP returns an IP field, keeping addr as a string if it isn't an IP
+func parseIP(addr string) Field {
+	if addr == "" {
+		return NewField("c-ip", nil)
+	}
+
+	if ip := net.ParseIP(addr); ip != nil {
+		return NewField("c-ip", ip)
+	}
+	return NewField("c-ip", addr)
+}
@@ -0,0 +1,79 @@
+package logfmt
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type response struct {
+	header http.Header
+	status int
+	size   int
+}
+
+func (r *response) Header() http.Header { return r.header }
+func (r *response) Status() int         { return r.status }
+func (r *response) Size() int           { return r.size }
+
+func TestValidIdentifier(t *testing.T) {
+	for _, id := range []string{"date", "time-taken", "c-ip", "s-computername", "sc-win32-status", "rs-bytes", "cs(User-Agent)", "sc(Content-Type)", "x-region"} {
+		if !ValidIdentifier(id) {
+			t.Errorf("expected %q to be valid", id)
+		}
+	}
+
+	for _, id := range []string{"", "ip", "z-ip", "cs-cookie", "cs()", "cs(User Agent)", "x-", "c-ip extra"} {
+		if ValidIdentifier(id) {
+			t.Errorf("expected %q to be invalid", id)
+		}
+	}
+}
+
+func TestIdentifiers(t *testing.T) {
+	var r = httptest.NewRequest(http.MethodPost, "http://example.com/a/b?c=1", strings.NewReader("body"))
+	r.RemoteAddr = "10.0.0.1:5000"
+	r.SetBasicAuth("frank", "secret")
+	r.Header.Set("User-Agent", "curl/8.0")
+	r = r.WithContext(context.WithValue(r.Context(), http.LocalAddrContextKey, &net.TCPAddr{IP: net.ParseIP("10.0.0.2"), Port: 8080}))
+
+	var resp = &response{header: http.Header{"Content-Type": {"text/plain"}}, status: 201, size: 5}
+
+	var e = NewEntry().Append(
+		ClientIP(r),
+		ServerIP(r),
+		ServerPort(r),
+		Username(r),
+		RequestMethod(r),
+		RequestURI(r),
+		RequestURIStem(r),
+		RequestURIQuery(r),
+		Host(r),
+		Version(r),
+		RequestBytes(r),
+		UserAgent(r),
+		Referer(r),
+		ResponseStatus(resp),
+		ResponseBytes(resp),
+		ResponseHeader(resp, "Content-Type"),
+		SiteName("W3SVC1"),
+		Win32Status(0),
+		Custom("region", "us-east-1"),
+	)
+
+	var kv = `c-ip=10.0.0.1 s-ip=10.0.0.2 s-port=8080 cs-username=frank cs-method=POST cs-uri="/a/b?c=1" cs-uri-stem=/a/b cs-uri-query="c=1" ` +
+		`cs-host=example.com cs-version=HTTP/1.1 cs-bytes=4 cs(User-Agent)=curl/8.0 cs(Referer)="" sc-status=201 sc-bytes=5 ` +
+		`sc(Content-Type)=text/plain s-sitename=W3SVC1 sc-win32-status=0 x-region=us-east-1`
+	if out := string(e.Encode(LogfmtEncoder)); out != kv {
+		t.Errorf("expected %q, got %q", kv, out)
+	}
+
+	for _, f := range e.Fields() {
+		if !ValidIdentifier(f.Name) {
+			t.Errorf("expected %q to be valid", f.Name)
+		}
+	}
+}
@@ -0,0 +1,284 @@
+package logfmt
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//Record is an entry read from an Extended Log File
+type Record struct {
+	Directive *Directive    //Directives in effect when the entry was read
+	Fields    []string      //Identifiers from #Fields
+	Values    []interface{} //Value of each field, nil if it was missing
+}
+
+//Get returns the value of the field with the identifier, and whether the record has the field
+func (r *Record) Get(field string) (interface{}, bool) {
+	for i, f := range r.Fields {
+		if f == field {
+			return r.Values[i], true
+		}
+	}
+	return nil, false
+}
+
+//MarshalJSON writes the record as an object of its fields in order, time-taken is written in seconds
+func (r *Record) MarshalJSON() ([]byte, error) {
+	var buff bytes.Buffer
+	buff.WriteByte('{')
+
+	for i, f := range r.Fields {
+		if i > 0 {
+			buff.WriteByte(',')
+		}
+
+		var v = r.Values[i]
+		if d, ok := v.(time.Duration); ok {
+			v = d.Seconds()
+		}
+
+		key, err := json.Marshal(f)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+
+		buff.Write(key)
+		buff.WriteByte(':')
+		buff.Write(value)
+	}
+
+	buff.WriteByte('}')
+	return buff.Bytes(), nil
+}
+
+//maxLine is the longest entry read, request headers such as cs(Cookie) can be long
+const maxLine = 1 << 20
+
+//Parser reads records from an Extended Log File, following directives as they change
+type Parser struct {
+	scanner   *bufio.Scanner
+	directive *Directive
+	line      int
+}
+
+//NewParser returns a parser of the Extended Log File read from r
+func NewParser(r io.Reader) *Parser {
+	var s = bufio.NewScanner(r)
+	s.Buffer(make([]byte, 0, 64*1024), maxLine)
+
+	return &Parser{
+		scanner:   s,
+		directive: new(Directive),
+	}
+}
+
+//Next returns the next record, or io.EOF once there are none left
+//
+//An entry that can't be parsed returns an error naming its line, the following entries can still be read.
+func (p *Parser) Next() (*Record, error) {
+	for p.scanner.Scan() {
+		p.line++
+		var line = strings.TrimRight(p.scanner.Text(), "\r")
+
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "#") {
+			p.readDirective(line[1:])
+			continue
+		}
+
+		if len(p.directive.Fields) == 0 {
+			return nil, fmt.Errorf("line %d: entry before #Fields directive", p.line)
+		}
+
+		values, err := split(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", p.line, err)
+		}
+
+		if len(values) != len(p.directive.Fields) {
+			return nil, fmt.Errorf("line %d: entry has %d fields, #Fields declares %d", p.line, len(values), len(p.directive.Fields))
+		}
+
+		var rec = &Record{
+			Directive: p.directive,
+			Fields:    p.directive.Fields,
+			Values:    make([]interface{}, len(values)),
+		}
+
+		for i, v := range values {
+			if rec.Values[i], err = typed(rec.Fields[i], v); err != nil {
+				return nil, fmt.Errorf("line %d: %s: %v", p.line, rec.Fields[i], err)
+			}
+		}
+
+		return rec, nil
+	}
+
+	if err := p.scanner.Err(); err != nil {
+		return nil, fmt.Errorf("line %d: %v", p.line+1, err)
+	}
+	return nil, io.EOF
+}
+
+//Records iterates over every record, stopping after the first error
+func (p *Parser) Records() iter.Seq2[*Record, error] {
+	return func(yield func(*Record, error) bool) {
+		for {
+			rec, err := p.Next()
+			if err == io.EOF {
+				return
+			}
+
+			if !yield(rec, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+//readDirective updates the directives in effect, records already returned keep the previous ones
+func (p *Parser) readDirective(line string) {
+	var i = strings.IndexByte(line, ':')
+	if i < 0 {
+		return
+	}
+
+	var d = *p.directive
+	var value = strings.TrimSpace(line[i+1:])
+
+	switch line[:i] {
+	case "Version":
+		d.Version = value
+	case "Software":
+		d.Software = value
+	case "Date":
+		d.Date = value
+	case "Start-Date":
+		d.StartDate = value
+	case "End-Date":
+		d.EndDate = value
+	case "Remark":
+		d.Remark = value
+	case "Fields":
+		d.Fields = strings.Fields(value)
+	default:
+		return
+	}
+
+	p.directive = &d
+}
+
+//ToJSONLines writes each record of the Extended Log File read from r to w as a JSON object per line
+func ToJSONLines(w io.Writer, r io.Reader) error {
+	var enc = json.NewEncoder(w)
+	for rec, err := range NewParser(r).Records() {
+		if err != nil {
+			return err
+		}
+
+		if err := enc.Encode(rec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//split separates the fields of an entry, unquoting quoted values, a dash is returned as a missing value
+func split(line string) ([]*string, error) {
+	var values []*string
+	for {
+		line = strings.TrimLeft(line, " \t")
+		if line == "" {
+			return values, nil
+		}
+
+		if line[0] != '"' {
+			var end = strings.IndexAny(line, " \t")
+			if end < 0 {
+				end = len(line)
+			}
+
+			var v = line[:end]
+			if v == "-" {
+				values = append(values, nil)
+			} else {
+				values = append(values, &v)
+			}
+			line = line[end:]
+			continue
+		}
+
+		//Quotes inside a quoted value are doubled
+		var b strings.Builder
+		var i = 1
+		for {
+			var q = strings.IndexByte(line[i:], '"')
+			if q < 0 {
+				return nil, errors.New("unterminated quoted value")
+			}
+
+			b.WriteString(line[i : i+q])
+			i += q + 1
+			if i < len(line) && line[i] == '"' {
+				b.WriteByte('"')
+				i++
+				continue
+			}
+			break
+		}
+
+		if i < len(line) && line[i] != ' ' && line[i] != '\t' {
+			return nil, errors.New("expected whitespace after quoted value")
+		}
+
+		var v = b.String()
+		values = append(values, &v)
+		line = line[i:]
+	}
+}
+
+//typed converts a value according to its field identifier
+//
+//time-taken is a time.Duration, written in seconds or as a Go duration, and statuses and byte counts are ints.
+func typed(field string, v *string) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	switch {
+	case field == "time-taken":
+		if d, err := time.ParseDuration(*v); err == nil {
+			return d, nil
+		}
+
+		secs, err := strconv.ParseFloat(*v, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid duration %q", *v)
+		}
+		return time.Duration(secs * float64(time.Second)), nil
+	case strings.HasSuffix(field, "status"), strings.HasSuffix(field, "bytes"):
+		n, err := strconv.Atoi(*v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", *v)
+		}
+		return n, nil
+	}
+
+	return *v, nil
+}
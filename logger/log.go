@@ -9,6 +9,8 @@ import (
 	"os"
 	"runtime"
 	"strings"
+	"sync/atomic"
+	"time"
 )
 
 //Logger is used for all logging
@@ -27,21 +29,110 @@ type Logger interface {
 	Close() error
 }
 
-//Log is used to log information to one or several files
+//LevelSetter changes the minimum level being written
+type LevelSetter interface {
+	SetLevel(string) error
+}
+
+//KVLogger writes entries with key/value pairs
+type KVLogger interface {
+	With(...interface{}) *Log
+	TraceKV(string, ...interface{})
+	InfoKV(string, ...interface{})
+	WarningKV(string, ...interface{})
+	ErrorKV(string, ...interface{})
+}
+
+//CustomLogger writes entries at custom levels
+type CustomLogger interface {
+	Log(string, ...interface{})
+	LogKV(string, string, ...interface{})
+}
+
+//Flusher writes queued entries
+type Flusher interface {
+	Flush() error
+}
+
+//Reopener reopens log files moved by external tools
+type Reopener interface {
+	Reopen() error
+}
+
+var (
+	_ Logger       = (*Log)(nil)
+	_ LevelSetter  = (*Log)(nil)
+	_ KVLogger     = (*Log)(nil)
+	_ CustomLogger = (*Log)(nil)
+	_ Flusher      = (*Log)(nil)
+	_ Reopener     = (*Log)(nil)
+)
+
+//Log is used to log information to a file and any number of other sinks
 type Log struct {
-	levels Levels
-	file   *os.File
-	path   string
+	*core
+	fields []Field //Attached to every entry, set by With
+}
+
+//core is shared by a Log and all of its children
+type core struct {
+	levels  LogLevels
+	encoder Encoder
+	file    *file
+	path    string
+	min     int32   //Minimum severity written, accessed atomically
+	sinks   []*Sink //The log file is always the first sink
+	async   *Async
+	sampler *sampler
+	exit    func(int) //Called by Fatal, os.Exit by default
+	queue   *queue    //Set when entries are written in the background
+
+	stack    bool //Attach stack traces to entries at or above stackMin
+	stackMin int32
+}
+
+//Option configures a Log when it's created
+type Option func(*Log) error
+
+//WithLevel discards entries below level
+func WithLevel(level string) Option {
+	return func(l *Log) error {
+		return l.SetLevel(level)
+	}
+}
+
+//WithEncoder sets the format entries are written in, TextEncoder is used by default
+func WithEncoder(enc Encoder) Option {
+	return func(l *Log) error {
+		if enc == nil {
+			return errors.New("encoder must be provided")
+		}
+
+		l.encoder = enc
+		return nil
+	}
+}
+
+//WithExitFunc replaces os.Exit as the func Fatal calls once the log is closed
+func WithExitFunc(exit func(code int)) Option {
+	return func(l *Log) error {
+		if exit == nil {
+			return errors.New("exit func must be provided")
+		}
+
+		l.exit = exit
+		return nil
+	}
 }
 
 //New returns a newly initialized log
-func New(path string) (*Log, error) {
+func New(path string, opts ...Option) (*Log, error) {
 	if path == "" {
 		return nil, errors.New("file path must be provided")
 	}
 
 	//Create log file and open for writing
-	l, err := new(Log).Open(path)
+	l, err := (&Log{core: new(core)}).Open(path)
 	if err != nil {
 		return nil, err
 	}
@@ -52,21 +143,68 @@ func New(path string) (*Log, error) {
 	}
 
 	//Set log levels and default log level
-	l.levels = DefaultLevels(l.file)
+	l.levels = DefaultLogLevels()
+	l.encoder = TextEncoder
+	l.exit = os.Exit
+	l.sinks = []*Sink{{w: l.file, closer: l.file}}
+
+	for _, opt := range opts {
+		if err := opt(l); err != nil {
+			l.Close()
+			return nil, err
+		}
+	}
+
+	if l.async != nil {
+		l.startAsync()
+	}
+
 	return l, nil
 }
 
+//SetLevel discards entries below level, it's safe to call while logging
+func (l *Log) SetLevel(level string) error {
+	sev, ok := l.severity(level)
+	if !ok {
+		return fmt.Errorf("unknown log level %q", level)
+	}
+
+	atomic.StoreInt32(&l.min, sev)
+	return nil
+}
+
+//Level returns the minimum level being written
+func (l *Log) Level() string {
+	var min = atomic.LoadInt32(&l.min)
+	for level, ll := range l.levels {
+		if ll.severity == min {
+			return level
+		}
+	}
+	return TRACE
+}
+
+//enabled reports whether entries at level are written
+func (l *Log) enabled(level string) bool {
+	sev, _ := l.severity(level)
+	return sev >= atomic.LoadInt32(&l.min)
+}
+
+//severity returns the order of level, unknown levels are treated as INFO
+func (l *Log) severity(level string) (int32, bool) {
+	if ll, ok := l.levels[level]; ok {
+		return ll.severity, true
+	}
+	return severity[INFO], false
+}
+
 //Open opens the specified log file
 func (l *Log) Open(path string) (*Log, error) {
-	//Check if directory exists
-	if dir, _ := splitFilepath(path); dir != "" {
-		if _, err := os.Stat(dir); os.IsNotExist(err) {
-			os.MkdirAll(dir, 0766)
-		}
+	if l.core == nil {
+		l.core = new(core)
 	}
 
-	//Open log file
-	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0766)
+	f, err := newFile(path)
 	if err != nil {
 		return l, err
 	}
@@ -75,11 +213,26 @@ func (l *Log) Open(path string) (*Log, error) {
 	return l, nil
 }
 
-//Size returns size of log file
+//openFile creates the file and any missing directories, and opens it for appending
+func openFile(path string) (*os.File, error) {
+	//Check if directory exists
+	if dir, _ := splitFilepath(path); dir != "" {
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			os.MkdirAll(dir, 0766)
+		}
+	}
+
+	//Open log file
+	return os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0766)
+}
+
+//Size returns size of log file, 0 if it can't be read and the error is written to stderr
 func (l *Log) Size() int64 {
 	fi, err := l.file.Stat()
 	if err != nil {
-		l.Fatal(err)
+		//Logging the error would write to the same file and could rotate it
+		fmt.Fprintf(os.Stderr, "logger: could not stat %s: %v\n", l.path, err)
+		return 0
 	}
 
 	return fi.Size()
@@ -87,109 +240,273 @@ func (l *Log) Size() int64 {
 
 //Trace level log entry
 func (l *Log) Trace(p ...interface{}) {
-	_, file, line, ok := runtime.Caller(1)
-	if ok {
-		_, file = splitFilepath(file)
-		l.levels[TRACE].Println(fmt.Sprintf("%s %d:", file, line), p)
+	if !l.enabled(TRACE) {
 		return
 	}
 
-	l.levels[TRACE].Println(p)
+	l.output(TRACE, message(p), errArgs(p)...)
 }
 
 //Info level log entry
 func (l *Log) Info(p ...interface{}) {
-	_, file, line, ok := runtime.Caller(1)
-	if ok {
-		_, file = splitFilepath(file)
-		l.levels[INFO].Println(fmt.Sprintf("%s %d:", file, line), p)
+	if !l.enabled(INFO) {
 		return
 	}
 
-	l.levels[INFO].Println(p)
+	l.output(INFO, message(p), errArgs(p)...)
 }
 
 //Warning level log entry
 func (l *Log) Warning(p ...interface{}) {
-	_, file, line, ok := runtime.Caller(1)
-	if ok {
-		_, file = splitFilepath(file)
-		l.levels[WARN].Println(fmt.Sprintf("%s %d:", file, line), p)
+	if !l.enabled(WARN) {
 		return
 	}
 
-	l.levels[WARN].Println(p)
+	l.output(WARN, message(p), errArgs(p)...)
 }
 
 //Error level log entry
 func (l *Log) Error(p ...interface{}) {
-	_, file, line, ok := runtime.Caller(1)
-	if ok {
-		_, file = splitFilepath(file)
-		l.levels[ERR].Println(fmt.Sprintf("%s %d:", file, line), p)
+	if !l.enabled(ERR) {
 		return
 	}
 
-	l.levels[ERR].Println(p)
+	l.output(ERR, message(p), errArgs(p)...)
 }
 
-//Fatal level log entry
+//Fatal level log entry, the log is flushed and closed before calling the exit func
 func (l *Log) Fatal(p ...interface{}) {
-	defer l.Close()
+	l.output(FATAL, message(p), errArgs(p)...)
+	l.Close()
+	l.exit(1)
+}
 
-	_, file, line, ok := runtime.Caller(1)
-	if ok {
-		_, file = splitFilepath(file)
-		l.levels[FATAL].Println(fmt.Sprintf("%s %d:", file, line), p)
+//Panic level log entry, the log is flushed before panicking with the message
+//
+//The log stays open so a recovered panic can keep logging.
+func (l *Log) Panic(p ...interface{}) {
+	var msg = message(p)
+	l.output(PANIC, msg, errArgs(p)...)
+	l.Flush()
+	panic(msg)
+}
+
+//With returns a child log that attaches the key/value pairs to every entry
+//
+//The child shares its parent's file and level, closing either closes both.
+func (l *Log) With(kv ...interface{}) *Log {
+	var fields = make([]Field, 0, len(l.fields)+len(kv)/2)
+	fields = append(fields, l.fields...)
+	fields = append(fields, toFields(kv)...)
+
+	return &Log{
+		core:   l.core,
+		fields: fields,
+	}
+}
+
+//TraceKV trace level log entry with key/value pairs
+func (l *Log) TraceKV(msg string, kv ...interface{}) {
+	if !l.enabled(TRACE) {
 		return
 	}
 
-	l.levels[FATAL].Fatalln(p)
+	l.output(TRACE, msg, kv...)
 }
 
-//Panic level log entry
-func (l *Log) Panic(p ...interface{}) {
-	defer l.Close()
+//InfoKV info level log entry with key/value pairs
+func (l *Log) InfoKV(msg string, kv ...interface{}) {
+	if !l.enabled(INFO) {
+		return
+	}
 
-	_, file, line, ok := runtime.Caller(1)
-	if ok {
-		path := strings.Split(file, "/")
-		file = path[len(path)-1]
-		l.levels[PANIC].Println(fmt.Sprintf("%s %d:", file, line), p)
+	l.output(INFO, msg, kv...)
+}
+
+//WarningKV warning level log entry with key/value pairs
+func (l *Log) WarningKV(msg string, kv ...interface{}) {
+	if !l.enabled(WARN) {
+		return
+	}
+
+	l.output(WARN, msg, kv...)
+}
+
+//ErrorKV error level log entry with key/value pairs
+func (l *Log) ErrorKV(msg string, kv ...interface{}) {
+	if !l.enabled(ERR) {
 		return
 	}
 
-	l.levels[PANIC].Panicln(p)
+	l.output(ERR, msg, kv...)
+}
+
+//Log writes an entry at level, which can be a custom level added with WithCustomLevel
+//
+//Entries at unknown levels are written with the severity of INFO.
+func (l *Log) Log(level string, p ...interface{}) {
+	if !l.enabled(level) {
+		return
+	}
+
+	l.output(level, message(p), errArgs(p)...)
+}
+
+//LogKV writes an entry with key/value pairs at level, which can be a custom level added with WithCustomLevel
+func (l *Log) LogKV(level, msg string, kv ...interface{}) {
+	if !l.enabled(level) {
+		return
+	}
+
+	l.output(level, msg, kv...)
 }
 
 //Printf is similar to fmt printf
 func (l *Log) Printf(format string, v ...interface{}) {
-	var msg = fmt.Sprintf(format, v...)
-	l.levels[INFO].Println(msg)
+	if !l.enabled(INFO) {
+		return
+	}
+
+	l.output(INFO, fmt.Sprintf(format, v...))
+}
+
+//output encodes and writes an entry
+func (l *Log) output(level, msg string, kv ...interface{}) {
+	//Skip output and the exported method that called it
+	var e = l.entry(level, msg, kv, 2)
+
+	_, file, line, ok := runtime.Caller(2)
+	if ok {
+		_, e.File = splitFilepath(file)
+		e.Line = line
+	}
+
+	l.dispatch(e)
+}
+
+//entry returns an entry with the Log's fields, kv, and the stack of the caller skip frames up when stack traces are enabled
+func (l *Log) entry(level, msg string, kv []interface{}, skip int) *Entry {
+	var e = &Entry{
+		Level:  level,
+		Time:   time.Now(),
+		Msg:    msg,
+		Fields: l.fields,
+	}
+
+	var sev, _ = l.severity(level)
+	var stack = l.stack && sev >= l.stackMin
+	if len(kv) > 0 || stack {
+		e.Fields = append(append(make([]Field, 0, len(l.fields)+len(kv)/2+1), l.fields...), toFields(kv)...)
+	}
+
+	if stack {
+		e.Fields = append(e.Fields, Field{"stack", stackTrace(skip + 1)})
+	}
+
+	return e
 }
 
-//Write ...
+//dispatch samples e, then queues it or writes it to every sink straight away
+func (l *Log) dispatch(e *Entry) {
+	if l.sampler != nil && !stops(e.Level) && !l.sampler.allow(e) {
+		return
+	}
+
+	l.send(e)
+}
+
+//send queues e or writes it to every sink straight away
+func (l *Log) send(e *Entry) {
+	e.level = l.levels[e.Level]
+
+	if l.queue != nil {
+		//Never drop the entry explaining why the program stopped
+		l.queue.push(e, stops(e.Level))
+	} else {
+		l.writeSinks(e)
+	}
+}
+
+//stops reports whether entries at level stop the program
+func stops(level string) bool {
+	return level == FATAL || level == PANIC
+}
+
+//writeSinks writes e to every sink, or only the level's own sink if it has one
+func (l *Log) writeSinks(e *Entry) {
+	var sinks = l.sinks
+	if e.level != nil && e.level.sink != nil {
+		sinks = []*Sink{e.level.sink}
+	}
+
+	for _, s := range sinks {
+		if err := s.write(e, l.encoder); err != nil {
+			fmt.Fprintf(os.Stderr, "logger: could not write entry: %v\n", err)
+		}
+	}
+}
+
+//Write writes b to the log file as is, it isn't queued when entries are written in the background
 func (l *Log) Write(b []byte) (int, error) {
-	n, err := l.file.Write(b)
-	if err != nil {
-		return n, err
+	return l.file.Write(b)
+}
+
+//allSinks returns the Log's sinks followed by the sinks of custom levels
+func (l *Log) allSinks() []*Sink {
+	var sinks = l.sinks
+	for _, ll := range l.levels {
+		if ll.sink != nil {
+			sinks = append(sinks[:len(sinks):len(sinks)], ll.sink)
+		}
 	}
+	return sinks
+}
 
-	err = l.file.Sync()
-	return n, err
+//SetHeader writes the bytes header returns at the start of each new log file
+//
+//The header is written before the first entry of a file that is empty when opened, rotated, or reopened.
+func (l *Log) SetHeader(header func() []byte) {
+	l.file.setHeader(header)
 }
 
 //GzipClose zips the old data before closing
 func (l *Log) GzipClose() error {
-	err := l.file.Close()
+	err := l.Close()
 	if err != nil {
 		return err
 	}
 
-	fp, err := os.Open(l.path)
+	return gzipFile(l.path)
+}
+
+//Close closes the log file and every sink, waiting for rotated files to finish compressing
+//
+//Queued entries are written first when entries are written in the background.
+func (l *Log) Close() error {
+	if l.sampler != nil {
+		l.sampler.stop()
+	}
+
+	if l.queue != nil {
+		l.queue.stop()
+	}
+
+	var err error
+	for _, s := range l.allSinks() {
+		if serr := s.Close(); serr != nil && err == nil {
+			err = serr
+		}
+	}
+	return err
+}
+
+//gzipFile compresses path into path.gz and removes the original
+func gzipFile(path string) error {
+	fp, err := os.Open(path)
 	if err != nil {
 		return err
 	}
+	defer fp.Close()
 
 	var buff bytes.Buffer
 	w := gzip.NewWriter(&buff)
@@ -208,27 +525,23 @@ func (l *Log) GzipClose() error {
 		return err
 	}
 
-	fp, err = os.Create(fmt.Sprintf("%s.gz", l.path))
+	gz, err := os.Create(fmt.Sprintf("%s.gz", path))
 	if err != nil {
 		return err
 	}
+	defer gz.Close()
 
-	_, err = buff.WriteTo(fp)
+	_, err = buff.WriteTo(gz)
 	if err != nil {
 		return err
 	}
 
-	err = fp.Sync()
+	err = gz.Sync()
 	if err != nil {
 		return err
 	}
 
-	return os.Remove(l.path)
-}
-
-//Close log file
-func (l *Log) Close() error {
-	return l.file.Close()
+	return os.Remove(path)
 }
 
 func splitFilepath(path string) (string, string) {
@@ -0,0 +1,843 @@
+package logger
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+func readLog(t *testing.T, path string) string {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+//testLog returns a Log writing to a temporary file, it's closed when the test ends
+func testLog(t *testing.T, opts ...Option) (*Log, string) {
+	t.Helper()
+
+	var path = filepath.Join(t.TempDir(), "test.log")
+	l, err := New(path, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { l.Close() })
+	return l, path
+}
+
+//readRotated returns the rotated files of path, decompressed and oldest first, followed by path
+func readRotated(t *testing.T, path string) []string {
+	t.Helper()
+
+	rotated, _, err := RotatedFiles(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var files = make([]string, 0, len(rotated)+1)
+	for i := len(rotated) - 1; i >= 0; i-- {
+		var b = []byte(readLog(t, rotated[i]))
+		if strings.HasSuffix(rotated[i], ".gz") {
+			r, err := gzip.NewReader(bytes.NewReader(b))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if b, err = io.ReadAll(r); err != nil {
+				t.Fatal(err)
+			}
+		}
+		files = append(files, string(b))
+	}
+
+	return append(files, readLog(t, path))
+}
+
+func TestLevelFiltering(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "test.log")
+
+	l, err := New(path, WithLevel(WARN))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	l.Trace("trace message")
+	l.Info("info message")
+	l.Printf("printf %s", "message")
+	l.Warning("warning message")
+	l.Error("error message")
+
+	var out = readLog(t, path)
+	for _, msg := range []string{"trace message", "info message", "printf message"} {
+		if strings.Contains(out, msg) {
+			t.Errorf("%q should have been discarded", msg)
+		}
+	}
+
+	for _, msg := range []string{"warning message", "error message"} {
+		if !strings.Contains(out, msg) {
+			t.Errorf("%q should have been written", msg)
+		}
+	}
+
+	if err := l.SetLevel(TRACE); err != nil {
+		t.Fatal(err)
+	}
+
+	l.Trace("trace after lowering")
+	if !strings.Contains(readLog(t, path), "trace after lowering") {
+		t.Error("trace should be written after lowering the level")
+	}
+
+	if l.Level() != TRACE {
+		t.Errorf("expected level %s, got %s", TRACE, l.Level())
+	}
+
+	if err := l.SetLevel("VERBOSE"); err == nil {
+		t.Error("expected error for unknown level")
+	}
+
+	if _, err := New(filepath.Join(t.TempDir(), "bad.log"), WithLevel("VERBOSE")); err == nil {
+		t.Error("expected error for unknown level option")
+	}
+}
+
+func TestEncoders(t *testing.T) {
+	var e = &Entry{
+		Level:  ERR,
+		Time:   time.Date(2018, 1, 2, 3, 4, 5, 0, time.UTC),
+		File:   "main.go",
+		Line:   12,
+		Msg:    "disk full",
+		Fields: []Field{{"path", "/var/log/app log"}, {"retries", 3}, {"err", errors.New("no space")}},
+	}
+
+	var obj map[string]interface{}
+	if err := json.Unmarshal(JSONEncoder(e), &obj); err != nil {
+		t.Fatal(err)
+	}
+
+	var expected = map[string]interface{}{
+		"level":   ERR,
+		"ts":      "2018-01-02T03:04:05Z",
+		"caller":  "main.go:12",
+		"msg":     "disk full",
+		"path":    "/var/log/app log",
+		"retries": float64(3),
+		"err":     "no space",
+	}
+	if !reflect.DeepEqual(obj, expected) {
+		t.Errorf("expected %v, got %v", expected, obj)
+	}
+
+	var logfmt = `level=ERROR ts=2018-01-02T03:04:05Z caller=main.go:12 msg="disk full" path="/var/log/app log" retries=3 err="no space"` + "\n"
+	if out := string(LogfmtEncoder(e)); out != logfmt {
+		t.Errorf("expected %q, got %q", logfmt, out)
+	}
+
+	var text = `[ERROR] 2018/01/02 03:04:05 main.go 12: [disk full] path="/var/log/app log" retries=3 err="no space"` + "\n"
+	if out := string(TextEncoder(e)); out != text {
+		t.Errorf("expected %q, got %q", text, out)
+	}
+}
+
+func TestWith(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "test.log")
+
+	l, err := New(path, WithEncoder(LogfmtEncoder))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	var child = l.With("request_id", "abc", "user", 7)
+	child.InfoKV("served", "status", 200)
+	child.With("attempt", 2).Warning("retrying")
+	l.Info("parent")
+
+	var lines = strings.Split(strings.TrimSpace(readLog(t, path)), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines, got %q", lines)
+	}
+
+	if !strings.HasSuffix(lines[0], "msg=served request_id=abc user=7 status=200") {
+		t.Errorf("unexpected child entry %q", lines[0])
+	}
+
+	if !strings.HasSuffix(lines[1], "msg=retrying request_id=abc user=7 attempt=2") {
+		t.Errorf("unexpected grandchild entry %q", lines[1])
+	}
+
+	if strings.Contains(lines[2], "request_id") {
+		t.Errorf("parent entry has child fields %q", lines[2])
+	}
+
+	//Children share the parent's level
+	l.SetLevel(ERR)
+	child.Info("discarded")
+	if strings.Contains(readLog(t, path), "discarded") {
+		t.Error("child should use the parent's level")
+	}
+}
+
+func TestRotation(t *testing.T) {
+	tests := []struct {
+		description string
+		rotation    Rotation
+		entries     int
+	}{
+		{"pruned backups", Rotation{MaxSize: 200, MaxBackups: 2}, 20},
+		//Many rotations happen within the same millisecond
+		{"every entry kept", Rotation{MaxSize: 500}, 2000},
+	}
+
+	for _, test := range tests {
+		l, path := testLog(t, WithRotation(test.rotation))
+		for i := 0; i < test.entries; i++ {
+			l.Info("rotation test entry", i)
+		}
+
+		if err := l.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		rotated, _, err := RotatedFiles(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if max := test.rotation.MaxBackups; max > 0 && len(rotated) != max {
+			t.Errorf("%s: expected %d backups, got %v", test.description, max, rotated)
+		}
+
+		for _, f := range rotated {
+			if !strings.HasSuffix(f, ".gz") {
+				t.Errorf("%s: expected %s to be compressed", test.description, f)
+			}
+		}
+
+		var files = readRotated(t, path)
+		var current = files[len(files)-1]
+		if int64(len(current)) > test.rotation.MaxSize {
+			t.Errorf("%s: current file is %d bytes, expected at most %d", test.description, len(current), test.rotation.MaxSize)
+		}
+
+		if !strings.Contains(current, fmt.Sprint("rotation test entry ", test.entries-1)) {
+			t.Errorf("%s: latest entry missing from current file", test.description)
+		}
+
+		if test.rotation.MaxBackups == 0 {
+			if n := strings.Count(strings.Join(files, ""), "rotation test entry"); n != test.entries {
+				t.Errorf("%s: expected %d entries across %d files, got %d", test.description, test.entries, len(files), n)
+			}
+		}
+	}
+}
+
+func TestReopen(t *testing.T) {
+	l, path := testLog(t)
+
+	l.Info("before rotation")
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+
+	//Still writing to the moved file until reopened
+	l.Info("after move")
+	if err := l.Reopen(); err != nil {
+		t.Fatal(err)
+	}
+	l.Info("after reopen")
+
+	var moved, current = readLog(t, path+".1"), readLog(t, path)
+	if !strings.Contains(moved, "before rotation") || !strings.Contains(moved, "after move") {
+		t.Errorf("unexpected moved file %q", moved)
+	}
+
+	if strings.Contains(current, "after move") || !strings.Contains(current, "after reopen") {
+		t.Errorf("unexpected current file %q", current)
+	}
+}
+
+func TestReopenOnSignal(t *testing.T) {
+	l, path := testLog(t)
+
+	var stop = l.ReopenOnSignal(syscall.SIGUSR1)
+	defer stop()
+
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGUSR1); err != nil {
+		t.Fatal(err)
+	}
+
+	//The signal is handled asynchronously
+	var deadline = time.Now().Add(5 * time.Second)
+	for {
+		if _, err := os.Stat(path); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("file was not reopened")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	//Stopping twice is a no-op
+	stop()
+	stop()
+}
+
+func TestSinks(t *testing.T) {
+	var dir = t.TempDir()
+	var path = filepath.Join(dir, "test.log")
+
+	var stderr bytes.Buffer
+	errSink, err := WriterSink(&stderr, SinkLevel(ERR))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jsonSink, err := FileSink(filepath.Join(dir, "test.json"), SinkEncoder(JSONEncoder))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := New(path, WithSink(errSink), WithSink(jsonSink))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l.Info("info message")
+	l.ErrorKV("error message", "code", 500)
+
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if out := stderr.String(); strings.Contains(out, "info message") || !strings.Contains(out, "[error message] code=500") {
+		t.Errorf("unexpected error sink output %q", out)
+	}
+
+	var lines = strings.Split(strings.TrimSpace(readLog(t, filepath.Join(dir, "test.json"))), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 JSON entries, got %q", lines)
+	}
+
+	var obj map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[1]), &obj); err != nil {
+		t.Fatal(err)
+	}
+
+	if obj["msg"] != "error message" || obj["code"] != float64(500) {
+		t.Errorf("unexpected JSON entry %v", obj)
+	}
+
+	if out := readLog(t, path); !strings.Contains(out, "info message") || !strings.Contains(out, "error message") {
+		t.Errorf("unexpected log file %q", out)
+	}
+}
+
+//blockingWriter holds up the flusher until release is closed
+type blockingWriter struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (w *blockingWriter) Write(b []byte) (int, error) {
+	w.started <- struct{}{}
+	<-w.release
+	return len(b), nil
+}
+
+func TestAsync(t *testing.T) {
+	var w = &blockingWriter{make(chan struct{}, 10), make(chan struct{})}
+
+	sink, err := WriterSink(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l, path := testLog(t, WithSink(sink), WithAsync(Async{Size: 1, FlushInterval: time.Hour}))
+
+	l.Info("first")
+	<-w.started
+
+	//The flusher is stuck on first, second fills the queue
+	l.Info("second")
+	l.Info("third")
+
+	if l.Dropped() != 1 {
+		t.Errorf("expected 1 dropped entry, got %d", l.Dropped())
+	}
+
+	close(w.release)
+	if err := l.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	var out = readLog(t, path)
+	if !strings.Contains(out, "first") || !strings.Contains(out, "second") || strings.Contains(out, "third") {
+		t.Errorf("unexpected log file %q", out)
+	}
+
+	l.Info("fourth")
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(readLog(t, path), "fourth") {
+		t.Error("Close should write queued entries")
+	}
+}
+
+func TestAsyncClose(t *testing.T) {
+
+	l, path := testLog(t, WithAsync(Async{Size: 16, Block: true}))
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				l.Info("racing close")
+			}
+		}()
+	}
+
+	time.Sleep(time.Millisecond)
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+
+	//Every entry is either written or counted as dropped
+	var written = uint64(strings.Count(readLog(t, path), "racing close"))
+	if written+l.Dropped() != 4000 {
+		t.Errorf("expected 4000 entries, %d written and %d dropped", written, l.Dropped())
+	}
+}
+
+func TestFatal(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "test.log")
+	var code = -1
+
+	l, err := New(path, WithAsync(Async{}), WithExitFunc(func(c int) { code = c }))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l.Info("before fatal")
+	l.Fatal("fatal message")
+
+	if code != 1 {
+		t.Errorf("expected exit code 1, got %d", code)
+	}
+
+	var out = readLog(t, path)
+	if !strings.Contains(out, "before fatal") || !strings.Contains(out, FATAL) || !strings.Contains(out, "fatal message") {
+		t.Errorf("queued entries should be written before exiting, got %q", out)
+	}
+}
+
+func TestSizeClosed(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "test.log")
+	var exited bool
+
+	var buff bytes.Buffer
+	sink, err := WriterSink(&buff)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := New(path, WithSink(sink), WithExitFunc(func(int) { exited = true }))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l.Info("entry")
+	if n := l.Size(); n == 0 {
+		t.Error("expected the size of the file")
+	}
+
+	l.Close()
+	if n := l.Size(); n != 0 || exited {
+		t.Errorf("expected 0 without exiting for a closed log, got %d", n)
+	}
+
+	if strings.Contains(buff.String(), "closed") {
+		t.Errorf("expected the stat error to stay out of the log, got %q", buff.String())
+	}
+}
+
+func TestPanic(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "test.log")
+
+	l, err := New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	func() {
+		defer func() {
+			if r := recover(); r != "panic message 42" {
+				t.Errorf("expected panic with the message, got %v", r)
+			}
+		}()
+
+		l.Panic("panic message", 42)
+	}()
+
+	//The log is still usable after recovering
+	l.Info("after recover")
+
+	var out = readLog(t, path)
+	if !strings.Contains(out, "[panic message 42]") || !strings.Contains(out, "after recover") {
+		t.Errorf("unexpected log file %q", out)
+	}
+}
+
+func TestColor(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "test.log")
+
+	var colored, plain bytes.Buffer
+	colorSink, err := WriterSink(&colored, SinkColor(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plainSink, err := WriterSink(&plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := New(path, WithSink(colorSink), WithSink(plainSink))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	l.Error("disk full")
+
+	if !strings.Contains(colored.String(), "\x1b[") {
+		t.Errorf("expected colour codes, got %q", colored.String())
+	}
+
+	for name, out := range map[string]string{"file": readLog(t, path), "writer": plain.String()} {
+		if !strings.HasPrefix(out, "[ERROR] ") {
+			t.Errorf("expected plain %s output, got %q", name, out)
+		}
+	}
+
+	t.Setenv("NO_COLOR", "1")
+	if ColorEnabled(os.Stderr) {
+		t.Error("NO_COLOR should disable colour")
+	}
+}
+
+func TestSlog(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "test.log")
+
+	var buff bytes.Buffer
+	sink, err := HandlerSink(slog.NewJSONHandler(&buff, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := New(path, WithEncoder(LogfmtEncoder), WithLevel(INFO), WithSink(sink))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	var sl = slog.New(l.Handler()).With("app", "api").WithGroup("req")
+	sl.Debug("discarded")
+	sl.Warn("slow request", "id", 7, slog.Group("user", "name", "ana"))
+
+	var out = strings.TrimSpace(readLog(t, path))
+	if strings.Contains(out, "discarded") {
+		t.Error("debug record should be discarded at INFO")
+	}
+
+	if !strings.HasPrefix(out, "level=WARNING ") || !strings.HasSuffix(out, `msg="slow request" app=api req.id=7 req.user.name=ana`) {
+		t.Errorf("unexpected log file %q", out)
+	}
+
+	if !strings.Contains(out, "caller=log_test.go:") {
+		t.Errorf("expected caller of the slog call, got %q", out)
+	}
+
+	var obj map[string]interface{}
+	if err := json.Unmarshal(buff.Bytes(), &obj); err != nil {
+		t.Fatal(err)
+	}
+
+	if obj["level"] != "WARN" || obj["msg"] != "slow request" || obj["req.id"] != float64(7) {
+		t.Errorf("unexpected handler sink record %v", obj)
+	}
+}
+
+func TestSampling(t *testing.T) {
+
+	l, path := testLog(t, WithSampling(Sampling{Interval: time.Hour, First: 2, Thereafter: 3}))
+
+	for i := 0; i < 10; i++ {
+		l.Error("connection refused")
+	}
+	l.Warning("connection refused")
+
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var out = readLog(t, path)
+	//1st, 2nd, 5th, and 8th pass, the warning is counted separately
+	if n := strings.Count(out, "[ERROR]"); n != 5 {
+		t.Errorf("expected 4 entries and a summary, got %d in %q", n, out)
+	}
+
+	if !strings.Contains(out, "[suppressed 6 similar messages] sampled_msg=\"connection refused\"") {
+		t.Errorf("expected summary when closing, got %q", out)
+	}
+
+	if strings.Count(out, "[WARNING]") != 1 {
+		t.Errorf("expected the warning to pass, got %q", out)
+	}
+
+	if thousands(4213) != "4,213" || thousands(1234567) != "1,234,567" || thousands(12) != "12" {
+		t.Error("unexpected thousands formatting")
+	}
+}
+
+func TestSamplingWindow(t *testing.T) {
+
+	l, path := testLog(t, WithSampling(Sampling{Interval: 20 * time.Millisecond, First: 1}))
+
+	for i := 0; i < 3; i++ {
+		l.Info("retrying")
+	}
+
+	//The summary is written when the window closes, without waiting for another entry
+	time.Sleep(100 * time.Millisecond)
+	if out := readLog(t, path); !strings.Contains(out, "suppressed 2 similar messages") {
+		t.Errorf("expected summary after the interval, got %q", out)
+	}
+}
+
+func TestStackTrace(t *testing.T) {
+
+	l, path := testLog(t, WithEncoder(JSONEncoder), WithStackTrace(ERR))
+
+	l.Warning("no stack")
+	l.Error("with stack")
+
+	var lines = strings.Split(strings.TrimSpace(readLog(t, path)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %q", lines)
+	}
+
+	if strings.Contains(lines[0], `"stack"`) {
+		t.Errorf("warning shouldn't have a stack %q", lines[0])
+	}
+
+	var obj struct {
+		Stack []string `json:"stack"`
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &obj); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(obj.Stack) == 0 || !strings.Contains(obj.Stack[0], "log_test.go") || !strings.HasSuffix(obj.Stack[0], ".TestStackTrace()") {
+		t.Errorf("expected the stack to start at the caller, got %v", obj.Stack)
+	}
+}
+
+func TestErrorChain(t *testing.T) {
+
+	l, path := testLog(t, WithEncoder(LogfmtEncoder))
+
+	var joined = errors.Join(errors.New("timeout"), errors.New("refused"))
+	var wrapped = fmt.Errorf("query users: %w", joined)
+
+	l.Error("request failed:", wrapped)
+	l.ErrorKV("request failed", "err", wrapped, "plain", errors.New("eof"))
+
+	var lines = strings.Split(strings.TrimSpace(readLog(t, path)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %q", lines)
+	}
+
+	var chain = `="query users: timeout\nrefused" %[1]s.cause="timeout\nrefused" %[1]s.cause.0=timeout %[1]s.cause.1=refused`
+	if expected := "error" + fmt.Sprintf(chain, "error"); !strings.HasSuffix(lines[0], expected) {
+		t.Errorf("expected %q, got %q", expected, lines[0])
+	}
+
+	if expected := "err" + fmt.Sprintf(chain, "err") + " plain=eof"; !strings.HasSuffix(lines[1], expected) {
+		t.Errorf("expected %q, got %q", expected, lines[1])
+	}
+}
+
+func TestSlogErrorChain(t *testing.T) {
+
+	l, path := testLog(t, WithEncoder(JSONEncoder), WithStackTrace(ERR))
+
+	var wrapped = fmt.Errorf("query users: %w", errors.Join(errors.New("timeout"), errors.New("refused")))
+	slog.New(l.Handler()).Error("request failed", "err", wrapped)
+
+	var obj struct {
+		Cause0 string   `json:"err.cause.0"`
+		Cause1 string   `json:"err.cause.1"`
+		Stack  []string `json:"stack"`
+	}
+	if err := json.Unmarshal([]byte(readLog(t, path)), &obj); err != nil {
+		t.Fatal(err)
+	}
+
+	if obj.Cause0 != "timeout" || obj.Cause1 != "refused" {
+		t.Errorf("expected the error chain, got %+v", obj)
+	}
+
+	if len(obj.Stack) == 0 || !strings.HasSuffix(obj.Stack[0], ".TestSlogErrorChain()") {
+		t.Errorf("expected the stack to start at the slog call, got %v", obj.Stack)
+	}
+
+	//Raw writes to a handler sink respect the handler's level
+	var buff bytes.Buffer
+	var hw = &handlerWriter{slog.NewTextHandler(&buff, &slog.HandlerOptions{Level: slog.LevelWarn})}
+	if _, err := hw.Write([]byte("raw write\n")); err != nil || buff.Len() != 0 {
+		t.Errorf("expected raw write to be discarded below the handler's level, got %q", buff.String())
+	}
+}
+
+func TestDefaultLevels(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "test.log")
+
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var levels = DefaultLevels(f)
+	if len(levels) != len(DefaultLogLevels()) {
+		t.Fatalf("expected a logger for each level, got %v", levels)
+	}
+
+	levels[WARN].Print("standard logger")
+	if out := readLog(t, path); !strings.Contains(out, WARN) || !strings.HasSuffix(out, "standard logger\n") {
+		t.Errorf("unexpected log file %q", out)
+	}
+
+	if prefix := DefaultLogLevels()[INFO].TextPrefix(false); prefix != "[INFO] " {
+		t.Errorf("unexpected prefix %q", prefix)
+	}
+
+	if prefix := DefaultLogLevels()[INFO].Prefix(); !strings.Contains(prefix, "[") || !strings.Contains(prefix, INFO) {
+		t.Errorf("unexpected prefix %q", prefix)
+	}
+
+	DefaultLogLevels()[ERR].Build(f).Print("built logger")
+	if out := readLog(t, path); !strings.Contains(out, ERR) || !strings.HasSuffix(out, "built logger\n") {
+		t.Errorf("unexpected log file %q", out)
+	}
+}
+
+func TestCustomLevels(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "test.log")
+
+	var audit bytes.Buffer
+	auditSink, err := WriterSink(&audit, SinkEncoder(LogfmtEncoder))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := New(path,
+		WithCustomLevel(Level{Name: "AUDIT", Severity: 15, Sink: auditSink}),
+		WithCustomLevel(Level{Name: "SECURITY", Severity: 35, Prefix: "SEC", Color: []color.Attribute{color.FgRed}}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	l.LogKV("AUDIT", "login", "user", 7)
+	l.Log("SECURITY", "blocked", "10.0.0.1")
+
+	if out := audit.String(); !strings.HasPrefix(out, "level=AUDIT ") || !strings.HasSuffix(out, "msg=login user=7\n") {
+		t.Errorf("unexpected audit sink output %q", out)
+	}
+
+	var out = readLog(t, path)
+	if strings.Contains(out, "login") {
+		t.Errorf("audit entries should only be written to their sink, got %q", out)
+	}
+
+	if !strings.HasPrefix(out, "[SEC] ") || !strings.Contains(out, "[blocked 10.0.0.1]") {
+		t.Errorf("unexpected log file %q", out)
+	}
+
+	if err := l.SetLevel("SECURITY"); err != nil {
+		t.Fatal(err)
+	}
+
+	l.Error("below security")
+	if strings.Contains(readLog(t, path), "below security") {
+		t.Error("ERROR should be discarded at SECURITY")
+	}
+
+	if l.Level() != "SECURITY" {
+		t.Errorf("expected level SECURITY, got %s", l.Level())
+	}
+
+	for _, lv := range []Level{{Name: "INFO", Severity: 12}, {Name: "NOTICE", Severity: 10}, {Name: "BAD NAME", Severity: 12}} {
+		if _, err := New(filepath.Join(t.TempDir(), "bad.log"), WithCustomLevel(lv)); err == nil {
+			t.Errorf("expected error for level %+v", lv)
+		}
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "test.log")
+
+	l, err := New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	if FromContext(NewContext(context.Background(), l)) != l {
+		t.Error("expected the log carried by the context")
+	}
+
+	var fallback = FromContext(context.Background())
+	if fallback == nil || fallback != FromContext(context.Background()) {
+		t.Fatal("expected the same standard error log without one in the context")
+	}
+	fallback.Trace("logged to standard error")
+
+	if len(fallback.sinks) != 1 || fallback.sinks[0].color != ColorEnabled(os.Stderr) {
+		t.Error("expected the fallback to colour standard error like StderrSink")
+	}
+}
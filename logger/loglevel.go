@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
 
 	"github.com/fatih/color"
 )
@@ -18,89 +19,162 @@ const (
 	PANIC = "PANIC"
 )
 
-//Levels contain each log level
+//severity orders the built in levels, entries below a Log's minimum level are discarded
+//
+//The gaps leave room for custom levels.
+var severity = map[string]int32{
+	TRACE: 0,
+	INFO:  10,
+	WARN:  20,
+	ERR:   30,
+	FATAL: 40,
+	PANIC: 50,
+}
+
+//Severity returns the order of a built in level, entries at higher severities are more important
+func Severity(level string) (int, bool) {
+	sev, ok := severity[level]
+	return int(sev), ok
+}
+
+//Levels contain a standard logger for each log level
+//
+//Deprecated: a Log no longer writes through a log.Logger per level, use LogLevels.
 type Levels map[string]*log.Logger
 
+//LogLevels contain each log level by name
+type LogLevels map[string]*LogLevel
+
 //LogLevel contains info about a log level
 type LogLevel struct {
-	color     func(...interface{}) string
-	colorText string
-	prefix    string
+	color       *color.Color
+	colorText   string
+	prefix      string
+	colorPrefix string
+	severity    int32
+	sink        *Sink //Receives every entry at the level instead of the Log's sinks
 }
 
-//DefaultLevels initializes and returns the available log levels
+//DefaultLevels initializes and returns a standard logger writing to file for each built in level
+//
+//Deprecated: use DefaultLogLevels.
 func DefaultLevels(file *os.File) Levels {
 	var levels = make(Levels)
+	for name, ll := range DefaultLogLevels() {
+		levels[name] = ll.Build(file)
+	}
+	return levels
+}
 
-	levels[TRACE] = trace().Build(file)
-	levels[INFO] = info().Build(file)
-	levels[WARN] = warning().Build(file)
-	levels[ERR] = err().Build(file)
-	levels[FATAL] = fatal().Build(file)
-	levels[PANIC] = panic().Build(file)
+//DefaultLogLevels initializes and returns the built in log levels
+func DefaultLogLevels() LogLevels {
+	var levels = make(LogLevels)
+
+	levels[TRACE] = trace()
+	levels[INFO] = info()
+	levels[WARN] = warning()
+	levels[ERR] = err()
+	levels[FATAL] = fatal()
+	levels[PANIC] = panicLevel()
 
 	return levels
 }
 
-//Build creates individual logger
+//Level describes a custom log level, logged with Log.Log and Log.LogKV
+type Level struct {
+	Name     string
+	Severity int               //Built in levels are TRACE 0, INFO 10, WARNING 20, ERROR 30, FATAL 40, and PANIC 50
+	Prefix   string            //Written in brackets by TextEncoder, Name by default
+	Color    []color.Attribute //Colour of the prefix on terminals
+	Sink     *Sink             //If set, entries at the level are written only to Sink
+}
+
+//WithCustomLevel adds a level to the Log, it must come before options that refer to the level
+//
+//Entries at a custom level never exit or panic, whatever their severity.
+func WithCustomLevel(lv Level) Option {
+	return func(l *Log) error {
+		if lv.Name == "" || strings.ContainsAny(lv.Name, " []=\"") {
+			return fmt.Errorf("invalid level name %q", lv.Name)
+		}
+
+		if _, ok := l.levels[lv.Name]; ok {
+			return fmt.Errorf("level %s is already defined", lv.Name)
+		}
+
+		for name, ll := range l.levels {
+			if ll.severity == int32(lv.Severity) {
+				return fmt.Errorf("severity %d is already used by %s", lv.Severity, name)
+			}
+		}
+
+		var prefix = lv.Prefix
+		if prefix == "" {
+			prefix = lv.Name
+		}
+
+		var ll = newLevel(prefix, int32(lv.Severity), lv.Color...)
+		ll.sink = lv.Sink
+		l.levels[lv.Name] = ll
+		return nil
+	}
+}
+
+//Build creates a standard logger writing to file with the level's prefix, coloured unless color.NoColor is set
+//
+//Deprecated: entries are written by a Log's sinks, log at the level with Log.Log.
 func (ll *LogLevel) Build(file *os.File) *log.Logger {
-	return log.New(file,
-		ll.Prefix(),
-		log.LstdFlags)
+	return log.New(file, ll.Prefix(), log.LstdFlags)
 }
 
-//Prefix ...
+//Prefix returns the level in brackets, coloured unless color.NoColor is set
 func (ll *LogLevel) Prefix() string {
-	if ll.prefix == "" {
-		ll.prefix = fmt.Sprintf("[%s] ", ll.color(ll.colorText))
+	return ll.TextPrefix(!color.NoColor)
+}
+
+//TextPrefix returns the level in brackets as written by TextEncoder, wrapped in ANSI colour codes if colored is set
+func (ll *LogLevel) TextPrefix(colored bool) string {
+	if colored {
+		return ll.colorPrefix
 	}
 	return ll.prefix
 }
 
-func trace() *LogLevel {
+//newLevel returns a level printed as text in the colour attrs describe
+func newLevel(text string, sev int32, attrs ...color.Attribute) *LogLevel {
+	var c = color.New(attrs...)
+	//Whether colour is used is decided per sink, not by fatih/color's check of stdout
+	c.EnableColor()
+
 	return &LogLevel{
-		color:     color.New(color.FgGreen).SprintFunc(),
-		colorText: TRACE,
-		prefix:    "",
+		color:       c,
+		colorText:   text,
+		prefix:      fmt.Sprintf("[%s] ", text),
+		colorPrefix: fmt.Sprintf("[%s] ", c.Sprint(text)),
+		severity:    sev,
 	}
 }
 
+func trace() *LogLevel {
+	return newLevel(TRACE, severity[TRACE], color.FgGreen)
+}
+
 func info() *LogLevel {
-	return &LogLevel{
-		color:     color.New(color.FgBlue).SprintFunc(),
-		colorText: INFO,
-		prefix:    "",
-	}
+	return newLevel(INFO, severity[INFO], color.FgBlue)
 }
 
 func warning() *LogLevel {
-	return &LogLevel{
-		color:     color.New(color.FgYellow).SprintFunc(),
-		colorText: WARN,
-		prefix:    "",
-	}
+	return newLevel(WARN, severity[WARN], color.FgYellow)
 }
 
 func err() *LogLevel {
-	return &LogLevel{
-		color:     color.New(color.FgRed).SprintFunc(),
-		colorText: ERR,
-		prefix:    "",
-	}
+	return newLevel(ERR, severity[ERR], color.FgRed)
 }
 
 func fatal() *LogLevel {
-	return &LogLevel{
-		color:     color.New(color.FgMagenta).SprintFunc(),
-		colorText: FATAL,
-		prefix:    "",
-	}
+	return newLevel(FATAL, severity[FATAL], color.FgMagenta)
 }
 
-func panic() *LogLevel {
-	return &LogLevel{
-		color:     color.New(color.FgBlack, color.BgWhite).SprintFunc(),
-		colorText: PANIC,
-		prefix:    "",
-	}
+func panicLevel() *LogLevel {
+	return newLevel(PANIC, severity[PANIC], color.FgBlack, color.BgWhite)
 }
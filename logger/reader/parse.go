@@ -0,0 +1,236 @@
+package reader
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/random9s/cinder/logger"
+)
+
+//textTime is the timestamp written by logger.TextEncoder
+const textTime = "2006/01/02 15:04:05"
+
+//ansi matches the colour codes written to terminals
+var ansi = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+//errNotEntry is returned for lines that weren't written by an encoder, such as continuation lines
+var errNotEntry = errors.New("not a log entry")
+
+//Parse converts a line written by logger.TextEncoder, JSONEncoder, or LogfmtEncoder back to an entry
+func Parse(line string) (*logger.Entry, error) {
+	line = strings.TrimRight(line, "\r\n")
+
+	switch {
+	case strings.HasPrefix(line, "{"):
+		return parseJSON(line)
+	case strings.HasPrefix(line, "level="):
+		return parseLogfmt(line)
+	case strings.HasPrefix(line, "["), strings.HasPrefix(line, "\x1b"):
+		return parseText(ansi.ReplaceAllString(line, ""))
+	}
+
+	return nil, errNotEntry
+}
+
+//parseText parses `[LEVEL] 2006/01/02 15:04:05 file.go 12: [msg] key=value`
+func parseText(line string) (*logger.Entry, error) {
+	var end = strings.Index(line, "] ")
+	if end < 0 {
+		return nil, errNotEntry
+	}
+
+	var e = &logger.Entry{Level: line[1:end]}
+	var rest = line[end+2:]
+	if len(rest) < len(textTime) {
+		return nil, errNotEntry
+	}
+
+	t, err := time.ParseInLocation(textTime, rest[:len(textTime)], time.Local)
+	if err != nil {
+		return nil, errNotEntry
+	}
+	e.Time = t
+	rest = strings.TrimPrefix(rest[len(textTime):], " ")
+
+	if !strings.HasPrefix(rest, "[") {
+		var i = strings.Index(rest, ": [")
+		if i < 0 {
+			return nil, errNotEntry
+		}
+
+		var sp = strings.LastIndexByte(rest[:i], ' ')
+		if sp < 0 {
+			return nil, errNotEntry
+		}
+
+		e.File = rest[:sp]
+		if e.Line, err = strconv.Atoi(rest[sp+1 : i]); err != nil {
+			return nil, errNotEntry
+		}
+		rest = rest[i+2:]
+	}
+
+	//The message can contain brackets, so use the first closing bracket followed by valid fields
+	for i := 1; i < len(rest); i++ {
+		if rest[i] != ']' {
+			continue
+		}
+
+		if i == len(rest)-1 {
+			e.Msg = rest[1:i]
+			return e, nil
+		}
+
+		if rest[i+1] != ' ' {
+			continue
+		}
+
+		if fields, err := parsePairs(rest[i+2:]); err == nil {
+			e.Msg = rest[1:i]
+			e.Fields = fields
+			return e, nil
+		}
+	}
+
+	return nil, errNotEntry
+}
+
+//parseLogfmt parses `level=INFO ts=... caller=file.go:12 msg="..." key=value`
+func parseLogfmt(line string) (*logger.Entry, error) {
+	pairs, err := parsePairs(line)
+	if err != nil {
+		return nil, err
+	}
+
+	var e = new(logger.Entry)
+	for _, f := range pairs {
+		var v = f.Value.(string)
+		switch f.Key {
+		case "level":
+			e.Level = v
+		case "ts":
+			if e.Time, err = time.Parse(time.RFC3339Nano, v); err != nil {
+				return nil, fmt.Errorf("invalid ts %q", v)
+			}
+		case "caller":
+			e.File, e.Line = splitCaller(v)
+		case "msg":
+			e.Msg = v
+		default:
+			e.Fields = append(e.Fields, f)
+		}
+	}
+
+	return e, nil
+}
+
+//parseJSON parses one object per line, keeping fields in the order they were written
+func parseJSON(line string) (*logger.Entry, error) {
+	var dec = json.NewDecoder(strings.NewReader(line))
+	dec.UseNumber()
+
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, errNotEntry
+	}
+
+	var e = new(logger.Entry)
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		var key, _ = tok.(string)
+
+		var v interface{}
+		if err := dec.Decode(&v); err != nil {
+			return nil, err
+		}
+
+		var s, _ = v.(string)
+		switch key {
+		case "level":
+			e.Level = s
+		case "ts":
+			if e.Time, err = time.Parse(time.RFC3339Nano, s); err != nil {
+				return nil, fmt.Errorf("invalid ts %q", s)
+			}
+		case "caller":
+			e.File, e.Line = splitCaller(s)
+		case "msg":
+			e.Msg = s
+		default:
+			e.Fields = append(e.Fields, logger.Field{Key: key, Value: v})
+		}
+	}
+
+	return e, nil
+}
+
+//parsePairs parses space separated key=value pairs, values may be quoted the way strconv.Quote does
+func parsePairs(s string) ([]logger.Field, error) {
+	var fields []logger.Field
+	for len(s) > 0 {
+		var eq = strings.IndexByte(s, '=')
+		if eq <= 0 || strings.ContainsAny(s[:eq], " \"") {
+			return nil, fmt.Errorf("invalid pair at %q", s)
+		}
+
+		var key = s[:eq]
+		var value string
+		s = s[eq+1:]
+
+		if strings.HasPrefix(s, `"`) {
+			quoted, err := strconv.QuotedPrefix(s)
+			if err != nil {
+				return nil, fmt.Errorf("invalid quoted value at %q", s)
+			}
+
+			value, _ = strconv.Unquote(quoted)
+			s = s[len(quoted):]
+		} else {
+			var sp = strings.IndexByte(s, ' ')
+			if sp < 0 {
+				sp = len(s)
+			}
+
+			value = s[:sp]
+			s = s[sp:]
+		}
+
+		if len(s) > 0 {
+			if s[0] != ' ' {
+				return nil, fmt.Errorf("expected space at %q", s)
+			}
+			s = s[1:]
+		}
+
+		fields = append(fields, logger.Field{Key: key, Value: value})
+	}
+
+	return fields, nil
+}
+
+//splitCaller splits file.go:12 into its file and line
+func splitCaller(caller string) (string, int) {
+	var i = strings.LastIndexByte(caller, ':')
+	if i < 0 {
+		return caller, 0
+	}
+
+	line, err := strconv.Atoi(caller[i+1:])
+	if err != nil {
+		return caller, 0
+	}
+	return caller[:i], line
+}
+
+//isGzip reports whether b starts with the gzip magic number
+func isGzip(b []byte) bool {
+	return bytes.HasPrefix(b, []byte{0x1f, 0x8b})
+}
@@ -0,0 +1,243 @@
+package reader
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/random9s/cinder/logger"
+)
+
+//maxLine is the longest line read, entries with stack traces can be long
+const maxLine = 1 << 20
+
+//pollInterval is how often Follow checks for new entries
+var pollInterval = 250 * time.Millisecond
+
+//Filter selects entries, the zero value matches every entry
+type Filter struct {
+	Level   string         //Minimum level, entries at levels this package doesn't know always match
+	Since   time.Time      //Entries before Since are skipped
+	Until   time.Time      //Entries after Until are skipped
+	Caller  string         //Prefix of the caller, such as handler.go or handler.go:42
+	Pattern *regexp.Regexp //Matched against the whole line
+}
+
+//Match reports whether e, read from line, is selected by the filter
+func (f *Filter) Match(e *logger.Entry, line string) bool {
+	if f.Level != "" {
+		min, _ := logger.Severity(f.Level)
+		if sev, ok := logger.Severity(e.Level); ok && sev < min {
+			return false
+		}
+	}
+
+	if !f.Since.IsZero() && e.Time.Before(f.Since) {
+		return false
+	}
+
+	if !f.Until.IsZero() && e.Time.After(f.Until) {
+		return false
+	}
+
+	if f.Caller != "" && !strings.HasPrefix(e.Caller(), f.Caller) {
+		return false
+	}
+
+	return f.Pattern == nil || f.Pattern.MatchString(line)
+}
+
+//Reader reads the entries selected by a filter, skipping lines that aren't entries
+type Reader struct {
+	scanner *bufio.Scanner
+	filter  Filter
+	line    string
+}
+
+//New returns a reader of the entries in r
+func New(r io.Reader, f Filter) *Reader {
+	var s = bufio.NewScanner(r)
+	s.Buffer(make([]byte, 0, 64*1024), maxLine)
+
+	return &Reader{
+		scanner: s,
+		filter:  f,
+	}
+}
+
+//Next returns the next selected entry, or io.EOF once there are none left
+func (r *Reader) Next() (*logger.Entry, error) {
+	for r.scanner.Scan() {
+		var line = r.scanner.Text()
+
+		e, err := Parse(line)
+		if err != nil || !r.filter.Match(e, line) {
+			continue
+		}
+
+		r.line = line
+		return e, nil
+	}
+
+	if err := r.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+//Line returns the line the last entry was read from
+func (r *Reader) Line() string {
+	return r.line
+}
+
+//Open opens a log file for reading, decompressing it if it was gzipped by rotation or GzipClose
+func Open(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var br = bufio.NewReader(f)
+	if magic, _ := br.Peek(2); !isGzip(magic) {
+		return struct {
+			io.Reader
+			io.Closer
+		}{br, f}, nil
+	}
+
+	gz, err := gzip.NewReader(br)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return struct {
+		io.Reader
+		io.Closer
+	}{gz, closers{gz, f}}, nil
+}
+
+type closers []io.Closer
+
+func (cs closers) Close() error {
+	var err error
+	for _, c := range cs {
+		if cerr := c.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+//Files returns the rotated and gzipped files of the log at path, oldest first, followed by path itself
+//
+//Only backups named by rotation and the file gzipped by GzipClose are returned.
+func Files(path string) ([]string, error) {
+	rotated, times, err := logger.RotatedFiles(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches = make([]string, 0, len(rotated)+2)
+	var rotatedAt = make([]time.Time, 0, len(times))
+	for i := len(rotated) - 1; i >= 0; i-- {
+		matches = append(matches, rotated[i])
+		rotatedAt = append(rotatedAt, times[i])
+	}
+
+	//The gzipped file holds the entries written up to GzipClose, it goes before backups rotated after it
+	if fi, err := os.Stat(path + ".gz"); err == nil {
+		var i = sort.Search(len(rotatedAt), func(i int) bool { return rotatedAt[i].After(fi.ModTime()) })
+		matches = append(matches[:i], append([]string{path + ".gz"}, matches[i:]...)...)
+	}
+
+	if _, err := os.Stat(path); err == nil {
+		matches = append(matches, path)
+	}
+
+	return matches, nil
+}
+
+//Follow calls fn for each selected entry appended to path until ctx is done or fn returns an error
+//
+//Entries already in the file are skipped. When the file is moved or truncated by rotation the new file is read from the start.
+func Follow(ctx context.Context, path string, filter Filter, fn func(e *logger.Entry, line string) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { f.Close() }()
+
+	offset, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+
+	var emit = func(line string) error {
+		line = strings.TrimRight(line, "\r\n")
+		if e, err := Parse(line); err == nil && filter.Match(e, line) {
+			return fn(e, line)
+		}
+		return nil
+	}
+
+	var br = bufio.NewReaderSize(f, 64*1024)
+	var partial string
+
+	for {
+		line, err := br.ReadString('\n')
+		offset += int64(len(line))
+		partial += line
+
+		if err == nil {
+			if err := emit(partial); err != nil {
+				return err
+			}
+			partial = ""
+			continue
+		}
+
+		if err != io.EOF {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(pollInterval):
+		}
+
+		//Keep reading the current file unless it was replaced or truncated
+		cur, cerr := f.Stat()
+		fi, err := os.Stat(path)
+		if err != nil || cerr != nil || (os.SameFile(cur, fi) && fi.Size() >= offset) {
+			continue
+		}
+
+		//Finish a replaced file first, lines may have been written to it since the last read
+		if !os.SameFile(cur, fi) {
+			rest, _ := io.ReadAll(br)
+			for _, line := range strings.SplitAfter(partial+string(rest), "\n") {
+				if err := emit(line); err != nil {
+					return err
+				}
+			}
+		}
+		partial = ""
+
+		nf, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+
+		f.Close()
+		f, offset = nf, 0
+		br.Reset(f)
+	}
+}
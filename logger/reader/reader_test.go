@@ -0,0 +1,187 @@
+package reader
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/random9s/cinder/logger"
+)
+
+func TestParse(t *testing.T) {
+	var e = &logger.Entry{
+		Level:  logger.ERR,
+		Time:   time.Date(2018, 1, 2, 3, 4, 5, 0, time.Local),
+		File:   "main.go",
+		Line:   12,
+		Msg:    "disk [sda] full",
+		Fields: []logger.Field{{Key: "path", Value: "/var/log/app log"}, {Key: "retries", Value: "3"}},
+	}
+
+	for name, enc := range map[string]logger.Encoder{"text": logger.TextEncoder, "json": logger.JSONEncoder, "logfmt": logger.LogfmtEncoder} {
+		parsed, err := Parse(string(enc(e)))
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+
+		if parsed.Level != e.Level || !parsed.Time.Equal(e.Time) || parsed.Caller() != e.Caller() || parsed.Msg != e.Msg {
+			t.Errorf("%s: expected %+v, got %+v", name, e, parsed)
+		}
+
+		if len(parsed.Fields) != 2 || parsed.Fields[0] != e.Fields[0] || parsed.Fields[1].Key != "retries" {
+			t.Errorf("%s: unexpected fields %v", name, parsed.Fields)
+		}
+	}
+
+	if _, err := Parse("\tat main.main()"); err == nil {
+		t.Error("expected error for a line that isn't an entry")
+	}
+}
+
+func TestFiles(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "app.log")
+
+	//Modification times run opposite to the rotation order, the file gzipped by GzipClose is ordered by its own
+	var names = []string{"app.log", "app.log.20201019T150405.000", "app.log.20181019T150406.000", "app.log.20181019T150405.000-1", "app.log.20181019T150405.000.gz", "app.log.bak", "app.log.lock", "app.log.2018.gz", "app.log.gz"}
+	var now = time.Now()
+	for i, name := range names {
+		var file = filepath.Join(filepath.Dir(path), name)
+		if err := os.WriteFile(file, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		var mod = now.Add(time.Duration(-i) * time.Second)
+		if name == "app.log.gz" {
+			mod = time.Date(2019, 1, 1, 0, 0, 0, 0, time.Local)
+		}
+		if err := os.Chtimes(file, mod, mod); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := Files(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var expected = []string{"app.log.20181019T150405.000.gz", "app.log.20181019T150405.000-1", "app.log.20181019T150406.000", "app.log.gz", "app.log.20201019T150405.000", "app.log"}
+	for i := range expected {
+		expected[i] = filepath.Join(filepath.Dir(path), expected[i])
+	}
+
+	if !reflect.DeepEqual(files, expected) {
+		t.Errorf("expected %v, got %v", expected, files)
+	}
+}
+
+func TestReadRotated(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "app.log")
+
+	l, err := logger.New(path, logger.WithEncoder(logger.JSONEncoder))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Info("old entry")
+	l.Error("old failure")
+	if err := l.GzipClose(); err != nil {
+		t.Fatal(err)
+	}
+
+	if l, err = logger.New(path); err != nil {
+		t.Fatal(err)
+	}
+	l.Warning("new warning")
+	l.Error("new failure")
+	l.Close()
+
+	files, err := Files(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(files) != 2 || files[0] != path+".gz" || files[1] != path {
+		t.Fatalf("unexpected files %v", files)
+	}
+
+	var msgs []string
+	for _, file := range files {
+		f, err := Open(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var r = New(f, Filter{Level: logger.WARN, Caller: "reader_test.go", Pattern: regexp.MustCompile("failure|warning")})
+		for {
+			e, err := r.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			msgs = append(msgs, e.Msg)
+		}
+		f.Close()
+	}
+
+	if len(msgs) != 3 || msgs[0] != "old failure" || msgs[1] != "new warning" || msgs[2] != "new failure" {
+		t.Errorf("unexpected entries %q", msgs)
+	}
+}
+
+func TestFollow(t *testing.T) {
+	pollInterval = 10 * time.Millisecond
+	var path = filepath.Join(t.TempDir(), "app.log")
+
+	l, err := logger.New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	l.Info("before following")
+
+	var msgs = make(chan string, 10)
+	var ctx, cancel = context.WithCancel(context.Background())
+	var done = make(chan error)
+	go func() {
+		done <- Follow(ctx, path, Filter{}, func(e *logger.Entry, _ string) error {
+			msgs <- e.Msg
+			return nil
+		})
+	}()
+
+	//Give Follow time to open the file before writing
+	time.Sleep(50 * time.Millisecond)
+	l.Info("appended")
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	l.Info("written to the moved file")
+	if err := l.Reopen(); err != nil {
+		t.Fatal(err)
+	}
+	l.Info("after reopen")
+
+	for _, expected := range []string{"appended", "written to the moved file", "after reopen"} {
+		select {
+		case msg := <-msgs:
+			if msg != expected {
+				t.Errorf("expected %q, got %q", expected, msg)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for %q", expected)
+		}
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
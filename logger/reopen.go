@@ -0,0 +1,59 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+//Reopen swaps every log file for a newly opened one at the same path
+//
+//External tools such as logrotate move the file out of the way and then ask the process to reopen it.
+//Entries logged concurrently are written to either the old or the new file, never lost.
+func (l *Log) Reopen() error {
+	var err error
+	for _, s := range l.allSinks() {
+		if f, ok := s.w.(*file); ok {
+			if ferr := f.Reopen(); ferr != nil && err == nil {
+				err = ferr
+			}
+		}
+	}
+	return err
+}
+
+//ReopenOnSignal calls Reopen whenever one of sigs is received, SIGHUP if none are given
+//
+//Call the returned func to stop handling the signals, it may be called more than once.
+func (l *Log) ReopenOnSignal(sigs ...os.Signal) (stop func()) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{syscall.SIGHUP}
+	}
+
+	var ch = make(chan os.Signal, 1)
+	var done = make(chan struct{})
+	signal.Notify(ch, sigs...)
+
+	go func() {
+		for {
+			select {
+			case <-ch:
+				if err := l.Reopen(); err != nil {
+					fmt.Fprintf(os.Stderr, "logger: could not reopen %s: %v\n", l.path, err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+		})
+	}
+}
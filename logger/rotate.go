@@ -0,0 +1,229 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//Rotation periods
+const (
+	Hourly = time.Hour
+	Daily  = 24 * time.Hour
+)
+
+//rotateTime is appended to the name of rotated files, it sorts chronologically
+//
+//Files rotated within the same millisecond get a sequence suffix, app.log.20181019T150405.000-1.
+const rotateTime = "20060102T150405.000"
+
+//Rotation describes when a log file is rotated and which rotated files are kept
+//
+//Rotated files are renamed with a timestamp (app.log.20181019T150405.000) and gzipped in the background.
+type Rotation struct {
+	MaxSize    int64         //Rotate before the file grows past this many bytes, 0 disables
+	Every      time.Duration //Rotate when crossing an Hourly or Daily boundary, 0 disables
+	MaxBackups int           //Number of rotated files to keep, 0 keeps all
+	MaxAge     time.Duration //Remove rotated files older than this, 0 keeps all
+}
+
+//WithRotation rotates the log file according to r
+func WithRotation(r Rotation) Option {
+	return func(l *Log) error {
+		return l.file.setRotation(r)
+	}
+}
+
+//SinkRotation rotates a FileSink according to r
+func SinkRotation(r Rotation) SinkOption {
+	return func(s *Sink) error {
+		f, ok := s.w.(*file)
+		if !ok {
+			return errors.New("rotation requires a file sink")
+		}
+		return f.setRotation(r)
+	}
+}
+
+func (f *file) setRotation(r Rotation) error {
+	if r.Every != 0 && r.Every != Hourly && r.Every != Daily {
+		return fmt.Errorf("unsupported rotation period %v", r.Every)
+	}
+
+	if r.MaxSize < 0 || r.MaxBackups < 0 || r.MaxAge < 0 {
+		return errors.New("rotation limits can't be negative")
+	}
+
+	f.mu.Lock()
+	f.rotation = &r
+	f.next = nextRotation(time.Now(), r.Every)
+	f.mu.Unlock()
+	return nil
+}
+
+//due reports whether writing n more bytes at now requires a rotation first
+func (r *Rotation) due(size int64, n int, now, next time.Time) bool {
+	//Never rotate an empty file, even if a single entry is larger than MaxSize
+	if r.MaxSize > 0 && size > 0 && size+int64(n) > r.MaxSize {
+		return true
+	}
+
+	return r.Every > 0 && !now.Before(next)
+}
+
+//nextRotation returns the first hour or day boundary after now in local time
+func nextRotation(now time.Time, every time.Duration) time.Time {
+	switch every {
+	case Hourly:
+		return time.Date(now.Year(), now.Month(), now.Day(), now.Hour()+1, 0, 0, 0, now.Location())
+	case Daily:
+		return time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location())
+	}
+	return time.Time{}
+}
+
+//rotate renames the current file and opens a new one, the caller must hold f.mu
+func (f *file) rotate(now time.Time) error {
+	if err := f.f.Close(); err != nil {
+		return err
+	}
+
+	var rotated = rotatedName(f.path, now)
+	var renameErr = os.Rename(f.path, rotated)
+
+	//Reopen even if the rename failed so logging can continue
+	fp, err := openFile(f.path)
+	if err != nil {
+		return err
+	}
+
+	f.f = fp
+	f.size = 0
+	f.next = nextRotation(now, f.rotation.Every)
+
+	if renameErr != nil {
+		return renameErr
+	}
+
+	var r = *f.rotation
+	f.bg.Add(1)
+	go func() {
+		defer f.bg.Done()
+
+		f.bgMu.Lock()
+		defer f.bgMu.Unlock()
+
+		if err := gzipFile(rotated); err != nil {
+			fmt.Fprintf(os.Stderr, "logger: could not compress %s: %v\n", rotated, err)
+		}
+
+		if err := prune(f.path, r, time.Now()); err != nil {
+			fmt.Fprintf(os.Stderr, "logger: could not remove old logs: %v\n", err)
+		}
+	}()
+
+	return nil
+}
+
+//rotatedName returns the name path is rotated to at now, adding a sequence number if a backup already has the name
+func rotatedName(path string, now time.Time) string {
+	var base = path + "." + now.Format(rotateTime)
+	var name = base
+	for seq := 1; exists(name) || exists(name+".gz"); seq++ {
+		name = base + "-" + strconv.Itoa(seq)
+	}
+	return name
+}
+
+func exists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+//parseRotated returns when name, a file rotated from path, was rotated and its sequence number within that millisecond
+func parseRotated(path, name string) (time.Time, int, bool) {
+	if !strings.HasPrefix(name, path+".") {
+		return time.Time{}, 0, false
+	}
+
+	var stamp = strings.TrimSuffix(strings.TrimPrefix(name, path+"."), ".gz")
+
+	var seq int
+	if i := strings.LastIndexByte(stamp, '-'); i >= 0 {
+		n, err := strconv.Atoi(stamp[i+1:])
+		if err != nil || n < 1 {
+			return time.Time{}, 0, false
+		}
+		stamp, seq = stamp[:i], n
+	}
+
+	t, err := time.ParseInLocation(rotateTime, stamp, time.Local)
+	if err != nil {
+		return time.Time{}, 0, false
+	}
+	return t, seq, true
+}
+
+//RotatedFiles returns the rotated files of path, compressed or not, newest first along with when they were rotated
+func RotatedFiles(path string) ([]string, []time.Time, error) {
+	matches, err := filepath.Glob(path + ".*")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var files = make([]string, 0, len(matches))
+	var times = make(map[string]time.Time)
+	var seqs = make(map[string]int)
+	for _, m := range matches {
+		t, seq, ok := parseRotated(path, m)
+		if !ok {
+			continue
+		}
+
+		files = append(files, m)
+		times[m], seqs[m] = t, seq
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		if ti, tj := times[files[i]], times[files[j]]; !ti.Equal(tj) {
+			return ti.After(tj)
+		}
+		return seqs[files[i]] > seqs[files[j]]
+	})
+
+	var sorted = make([]time.Time, len(files))
+	for i, f := range files {
+		sorted[i] = times[f]
+	}
+
+	return files, sorted, nil
+}
+
+//prune removes rotated files past the backup count or age limits
+func prune(path string, r Rotation, now time.Time) error {
+	if r.MaxBackups == 0 && r.MaxAge == 0 {
+		return nil
+	}
+
+	files, times, err := RotatedFiles(path)
+	if err != nil {
+		return err
+	}
+
+	for i, f := range files {
+		var tooMany = r.MaxBackups > 0 && i >= r.MaxBackups
+		var tooOld = r.MaxAge > 0 && now.Sub(times[i]) > r.MaxAge
+		if tooMany || tooOld {
+			if err := os.Remove(f); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
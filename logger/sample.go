@@ -0,0 +1,137 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+//Sampling limits how often the same message is written at the same level
+//
+//Within each interval the First entries pass, then 1 in every Thereafter, the rest are counted.
+//When the interval ends a summary entry reports how many were suppressed. FATAL and PANIC are never sampled.
+type Sampling struct {
+	Interval   time.Duration
+	First      int
+	Thereafter int //0 suppresses every entry after First
+}
+
+//WithSampling samples repeated entries according to s
+func WithSampling(s Sampling) Option {
+	return func(l *Log) error {
+		if s.Interval <= 0 {
+			return errors.New("sampling interval must be positive")
+		}
+
+		if s.First < 0 || s.Thereafter < 0 {
+			return errors.New("sampling limits can't be negative")
+		}
+
+		l.sampler = &sampler{
+			cfg:    s,
+			counts: make(map[sampleKey]*sampleCount),
+			emit:   l.send,
+		}
+		return nil
+	}
+}
+
+type sampleKey struct {
+	level, msg string
+}
+
+type sampleCount struct {
+	seen       int
+	suppressed int
+}
+
+//sampler counts entries in fixed windows starting with the first entry after the previous window closed
+type sampler struct {
+	mu     sync.Mutex
+	cfg    Sampling
+	start  time.Time
+	counts map[sampleKey]*sampleCount
+	timer  *time.Timer //Closes a window that suppressed entries
+	emit   func(*Entry)
+}
+
+//allow reports whether e should be written
+func (s *sampler) allow(e *Entry) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var now = time.Now()
+	if s.start.IsZero() || now.Sub(s.start) >= s.cfg.Interval {
+		s.closeWindow()
+		s.start = now
+	}
+
+	var key = sampleKey{e.Level, e.Msg}
+	var c = s.counts[key]
+	if c == nil {
+		c = new(sampleCount)
+		s.counts[key] = c
+	}
+
+	c.seen++
+	if c.seen <= s.cfg.First || (s.cfg.Thereafter > 0 && (c.seen-s.cfg.First)%s.cfg.Thereafter == 0) {
+		return true
+	}
+
+	c.suppressed++
+	if s.timer == nil {
+		var start = s.start
+		s.timer = time.AfterFunc(s.cfg.Interval-now.Sub(start), func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+
+			//A new window may have started while waiting for the lock
+			if s.start.Equal(start) {
+				s.closeWindow()
+				s.start = time.Time{}
+			}
+		})
+	}
+
+	return false
+}
+
+//closeWindow writes a summary for each message that was suppressed and resets the counts, the caller must hold s.mu
+func (s *sampler) closeWindow() {
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+
+	for key, c := range s.counts {
+		if c.suppressed > 0 {
+			s.emit(&Entry{
+				Level:  key.level,
+				Time:   time.Now(),
+				Msg:    fmt.Sprintf("suppressed %s similar messages", thousands(c.suppressed)),
+				Fields: []Field{{"sampled_msg", key.msg}},
+			})
+		}
+	}
+
+	s.counts = make(map[sampleKey]*sampleCount)
+}
+
+//stop writes the summary of the current window
+func (s *sampler) stop() {
+	s.mu.Lock()
+	s.closeWindow()
+	s.start = time.Time{}
+	s.mu.Unlock()
+}
+
+//thousands formats n with comma separators, 4213 is 4,213
+func thousands(n int) string {
+	var s = strconv.Itoa(n)
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
@@ -0,0 +1,158 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+//Sink is a destination for log entries with its own minimum level and encoder
+type Sink struct {
+	mu      sync.Mutex
+	w       io.Writer
+	closer  io.Closer //Only set when the sink owns w
+	encoder Encoder   //Falls back to the Log's encoder when nil
+	min     int32     //Minimum severity written, accessed atomically
+	color   bool      //Whether level prefixes are coloured
+}
+
+//SinkOption configures a Sink when it's created
+type SinkOption func(*Sink) error
+
+//entryWriter is implemented by writers that need more than the encoded entry, such as syslog priorities
+type entryWriter interface {
+	writeEntry(e *Entry, b []byte) error
+}
+
+//SinkLevel discards entries below level
+func SinkLevel(level string) SinkOption {
+	return func(s *Sink) error {
+		return s.SetLevel(level)
+	}
+}
+
+//SinkEncoder sets the format entries are written to the sink in
+func SinkEncoder(enc Encoder) SinkOption {
+	return func(s *Sink) error {
+		if enc == nil {
+			return errors.New("encoder must be provided")
+		}
+
+		s.encoder = enc
+		return nil
+	}
+}
+
+//WriterSink writes entries to w, it's never closed by the Log
+func WriterSink(w io.Writer, opts ...SinkOption) (*Sink, error) {
+	if w == nil {
+		return nil, errors.New("writer must be provided")
+	}
+
+	return newSink(w, nil, opts)
+}
+
+//StderrSink writes entries to standard error
+func StderrSink(opts ...SinkOption) (*Sink, error) {
+	return newSink(os.Stderr, nil, opts)
+}
+
+//FileSink appends entries to the file at path, use SinkRotation to rotate it
+func FileSink(path string, opts ...SinkOption) (*Sink, error) {
+	if path == "" {
+		return nil, errors.New("file path must be provided")
+	}
+
+	f, err := newFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := newSink(f, f, opts)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func newSink(w io.Writer, c io.Closer, opts []SinkOption) (*Sink, error) {
+	var s = &Sink{
+		w:      w,
+		closer: c,
+		color:  ColorEnabled(w),
+	}
+
+	for _, opt := range opts {
+		if err := opt(s); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+//WithSink writes entries to s in addition to the log file
+//
+//The Log's level is checked first, so s only sees entries at or above both levels.
+func WithSink(s *Sink) Option {
+	return func(l *Log) error {
+		if s == nil {
+			return errors.New("sink must be provided")
+		}
+
+		l.sinks = append(l.sinks, s)
+		return nil
+	}
+}
+
+//SetLevel discards entries below level, one of the built in levels, it's safe to call while logging
+func (s *Sink) SetLevel(level string) error {
+	sev, ok := severity[level]
+	if !ok {
+		return fmt.Errorf("unknown log level %q", level)
+	}
+
+	atomic.StoreInt32(&s.min, sev)
+	return nil
+}
+
+//write encodes e with the sink's encoder, or enc if it doesn't have one
+func (s *Sink) write(e *Entry, enc Encoder) error {
+	if e.severity() < atomic.LoadInt32(&s.min) {
+		return nil
+	}
+
+	if s.encoder != nil {
+		enc = s.encoder
+	}
+
+	if s.color != e.color {
+		var ce = *e
+		ce.color = s.color
+		e = &ce
+	}
+
+	var b = enc(e)
+	if ew, ok := s.w.(entryWriter); ok {
+		return ew.writeEntry(e, b)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.w.Write(b)
+	return err
+}
+
+//Close closes the sink's writer if the sink opened it
+func (s *Sink) Close() error {
+	if s.closer == nil {
+		return nil
+	}
+	return s.closer.Close()
+}
@@ -0,0 +1,52 @@
+//go:build !windows && !plan9
+
+package logger
+
+import (
+	"log/syslog"
+)
+
+//SyslogSink writes entries to the local syslog daemon over its unix socket
+//
+//Each entry is sent with the priority matching its level, tag identifies the program.
+func SyslogSink(tag string, opts ...SinkOption) (*Sink, error) {
+	w, err := syslog.New(syslog.LOG_INFO|syslog.LOG_USER, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := newSink(&syslogWriter{w}, w, opts)
+	if err != nil {
+		w.Close()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+type syslogWriter struct {
+	w *syslog.Writer
+}
+
+func (sw *syslogWriter) Write(b []byte) (int, error) {
+	return sw.w.Write(b)
+}
+
+func (sw *syslogWriter) writeEntry(e *Entry, b []byte) error {
+	var msg = string(b)
+
+	switch e.Level {
+	case TRACE:
+		return sw.w.Debug(msg)
+	case WARN:
+		return sw.w.Warning(msg)
+	case ERR:
+		return sw.w.Err(msg)
+	case FATAL:
+		return sw.w.Crit(msg)
+	case PANIC:
+		return sw.w.Alert(msg)
+	}
+
+	return sw.w.Info(msg)
+}
@@ -0,0 +1,164 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"runtime"
+	"strings"
+	"time"
+)
+
+//Handler returns an slog.Handler that writes records to l's file and sinks
+//
+//Debug records are logged at TRACE and anything above Error at ERROR, records never exit or panic.
+//Groups are flattened into dotted keys, so slog.Group("req", "id", 7) is written as req.id=7.
+func (l *Log) Handler() slog.Handler {
+	return &handler{log: l}
+}
+
+type handler struct {
+	log    *Log
+	prefix string //Joined group names followed by a dot
+}
+
+func (h *handler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.log.enabled(fromSlog(level))
+}
+
+func (h *handler) Handle(_ context.Context, r slog.Record) error {
+	var kv []interface{}
+	r.Attrs(func(a slog.Attr) bool {
+		kv = appendAttr(kv, h.prefix, a)
+		return true
+	})
+
+	//Skip Handle, the frames of log/slog are skipped by stackTrace
+	var e = h.log.entry(fromSlog(r.Level), r.Message, kv, 1)
+	e.Time = r.Time
+
+	if r.PC != 0 {
+		var frame, _ = runtime.CallersFrames([]uintptr{r.PC}).Next()
+		_, e.File = splitFilepath(frame.File)
+		e.Line = frame.Line
+	}
+
+	h.log.dispatch(e)
+	return nil
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var kv []interface{}
+	for _, a := range attrs {
+		kv = appendAttr(kv, h.prefix, a)
+	}
+	var fields = toFields(kv)
+
+	var l = &Log{
+		core:   h.log.core,
+		fields: append(append(make([]Field, 0, len(h.log.fields)+len(fields)), h.log.fields...), fields...),
+	}
+
+	return &handler{log: l, prefix: h.prefix}
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	return &handler{log: h.log, prefix: h.prefix + name + "."}
+}
+
+//appendAttr flattens a into key/value pairs, prefixing keys with the enclosing groups
+func appendAttr(kv []interface{}, prefix string, a slog.Attr) []interface{} {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return kv
+	}
+
+	if a.Value.Kind() == slog.KindGroup {
+		//Attrs of a group without a key are inlined
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+
+		for _, ga := range a.Value.Group() {
+			kv = appendAttr(kv, prefix, ga)
+		}
+		return kv
+	}
+
+	return append(kv, prefix+a.Key, a.Value.Any())
+}
+
+//fromSlog returns the level a record is logged at
+func fromSlog(level slog.Level) string {
+	switch {
+	case level < slog.LevelInfo:
+		return TRACE
+	case level < slog.LevelWarn:
+		return INFO
+	case level < slog.LevelError:
+		return WARN
+	}
+	return ERR
+}
+
+//toSlog returns the slog level of an entry, FATAL and PANIC are above Error
+func toSlog(level string) slog.Level {
+	switch level {
+	case TRACE:
+		return slog.LevelDebug
+	case WARN:
+		return slog.LevelWarn
+	case ERR:
+		return slog.LevelError
+	case FATAL:
+		return slog.LevelError + 4
+	case PANIC:
+		return slog.LevelError + 8
+	}
+	return slog.LevelInfo
+}
+
+//HandlerSink passes entries to h as slog records, the sink's encoder isn't used
+//
+//Fields are added to the record as attrs, so h decides how they're formatted.
+func HandlerSink(h slog.Handler, opts ...SinkOption) (*Sink, error) {
+	if h == nil {
+		return nil, errors.New("handler must be provided")
+	}
+
+	return newSink(&handlerWriter{h}, nil, opts)
+}
+
+type handlerWriter struct {
+	h slog.Handler
+}
+
+//Write passes b to the handler as an info record
+func (hw *handlerWriter) Write(b []byte) (int, error) {
+	var ctx = context.Background()
+	if !hw.h.Enabled(ctx, slog.LevelInfo) {
+		return len(b), nil
+	}
+
+	var r = slog.NewRecord(time.Now(), slog.LevelInfo, strings.TrimSuffix(string(b), "\n"), 0)
+	return len(b), hw.h.Handle(ctx, r)
+}
+
+func (hw *handlerWriter) writeEntry(e *Entry, _ []byte) error {
+	var ctx = context.Background()
+	var level = toSlog(e.Level)
+	if !hw.h.Enabled(ctx, level) {
+		return nil
+	}
+
+	var r = slog.NewRecord(e.Time, level, e.Msg, 0)
+	for _, f := range e.Fields {
+		r.AddAttrs(slog.Any(f.Key, f.Value))
+	}
+
+	return hw.h.Handle(ctx, r)
+}
@@ -0,0 +1,86 @@
+package logger
+
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+//WithStackTrace attaches the stack of the logging goroutine to entries at or above level as the stack field
+func WithStackTrace(level string) Option {
+	return func(l *Log) error {
+		sev, ok := l.severity(level)
+		if !ok {
+			return fmt.Errorf("unknown log level %q", level)
+		}
+
+		l.stack = true
+		l.stackMin = sev
+		return nil
+	}
+}
+
+//stackTrace returns `file:line func()` for each frame, skipping the callers of stackTrace the way runtime.Caller does
+//
+//Frames in log/slog directly above the skipped ones are skipped too, so records logged through Handler start at the caller.
+func stackTrace(skip int) []string {
+	var pcs = make([]uintptr, 64)
+	var n = runtime.Callers(skip+2, pcs)
+	var frames = runtime.CallersFrames(pcs[:n])
+
+	var stack = make([]string, 0, n)
+	for {
+		frame, more := frames.Next()
+		if len(stack) > 0 || !strings.HasPrefix(frame.Function, "log/slog.") {
+			stack = append(stack, fmt.Sprintf("%s:%d %s()", frame.File, frame.Line, frame.Function))
+		}
+		if !more {
+			break
+		}
+	}
+	return stack
+}
+
+//errArgs returns a key/value pair for each wrapped error in p, keyed error, error2, error3...
+func errArgs(p []interface{}) []interface{} {
+	var kv []interface{}
+	for _, arg := range p {
+		if err, ok := arg.(error); ok && wraps(err) {
+			var key = "error"
+			if len(kv) > 0 {
+				key += strconv.Itoa(len(kv)/2 + 1)
+			}
+			kv = append(kv, key, err)
+		}
+	}
+	return kv
+}
+
+//wraps reports whether err implements Unwrap
+func wraps(err error) bool {
+	switch err.(type) {
+	case interface{ Unwrap() error }, interface{ Unwrap() []error }:
+		return true
+	}
+	return false
+}
+
+//errorChain adds key and a field for each error err wraps
+//
+//A wrapped error is keyed key.cause, and each error joined by errors.Join is keyed key.0, key.1...
+func errorChain(fields []Field, key string, err error) []Field {
+	fields = append(fields, Field{key, err.Error()})
+
+	switch u := err.(type) {
+	case interface{ Unwrap() error }:
+		if cause := u.Unwrap(); cause != nil {
+			fields = errorChain(fields, key+".cause", cause)
+		}
+	case interface{ Unwrap() []error }:
+		for i, branch := range u.Unwrap() {
+			fields = errorChain(fields, key+"."+strconv.Itoa(i), branch)
+		}
+	}
+	return fields
+}
@@ -0,0 +1,188 @@
+package accesslog
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/random9s/cinder/logger"
+	logfmt "github.com/random9s/cinder/logger/format"
+)
+
+//clfTime is the timestamp of Common and Combined Log Format entries
+const clfTime = "02/Jan/2006:15:04:05 -0700"
+
+//DefaultFields are written by Extended when no fields are given
+var DefaultFields = []string{"date", "time", "c-ip", "cs-method", "cs-uri-stem", "cs-uri-query", "sc-status", "sc-bytes", "time-taken", "cs(User-Agent)"}
+
+//request is a served request passed to the field constructors
+type request struct {
+	r     *http.Request
+	w     *ResponseWriter
+	start time.Time
+	taken time.Duration
+}
+
+//end returns when the response was completed, W3C date and time fields are written at completion
+func (req *request) end() time.Time {
+	return req.start.Add(req.taken)
+}
+
+//fields builds the value of each supported W3C field identifier, other than headers
+var fields = map[string]func(*request) logfmt.Field{
+	"date":           func(req *request) logfmt.Field { return logfmt.Date(req.end()) },
+	"time":           func(req *request) logfmt.Field { return logfmt.Time(req.end()) },
+	"time-taken":     func(req *request) logfmt.Field { return logfmt.TimeTaken(req.taken) },
+	"c-ip":           func(req *request) logfmt.Field { return logfmt.ClientIP(req.r) },
+	"s-ip":           func(req *request) logfmt.Field { return logfmt.ServerIP(req.r) },
+	"s-port":         func(req *request) logfmt.Field { return logfmt.ServerPort(req.r) },
+	"s-computername": func(req *request) logfmt.Field { return logfmt.ComputerName() },
+	"cs-username":    func(req *request) logfmt.Field { return logfmt.Username(req.r) },
+	"cs-method":      func(req *request) logfmt.Field { return logfmt.RequestMethod(req.r) },
+	"cs-uri":         func(req *request) logfmt.Field { return logfmt.RequestURI(req.r) },
+	"cs-uri-stem":    func(req *request) logfmt.Field { return logfmt.RequestURIStem(req.r) },
+	"cs-uri-query":   func(req *request) logfmt.Field { return logfmt.RequestURIQuery(req.r) },
+	"cs-host":        func(req *request) logfmt.Field { return logfmt.Host(req.r) },
+	"cs-version":     func(req *request) logfmt.Field { return logfmt.Version(req.r) },
+	"cs-bytes":       func(req *request) logfmt.Field { return logfmt.RequestBytes(req.r) },
+	"sc-status":      func(req *request) logfmt.Field { return logfmt.ResponseStatus(req.w) },
+	"sc-bytes":       func(req *request) logfmt.Field { return logfmt.ResponseBytes(req.w) },
+}
+
+//header matches cs(Name) and sc(Name) identifiers
+var header = regexp.MustCompile(`^(cs|sc)\((.+)\)$`)
+
+//builder returns the func building the field with the identifier
+func builder(id string) (func(*request) logfmt.Field, bool) {
+	if build, ok := fields[id]; ok {
+		return build, true
+	}
+
+	var m = header.FindStringSubmatch(id)
+	if m == nil || !logfmt.ValidIdentifier(id) {
+		return nil, false
+	}
+
+	var name = m[2]
+	if m[1] == "cs" {
+		return func(req *request) logfmt.Field { return logfmt.RequestHeader(req.r, name) }, true
+	}
+	return func(req *request) logfmt.Field { return logfmt.ResponseHeader(req.w, name) }, true
+}
+
+type accessLogHandler struct {
+	h     http.Handler
+	write func(*request) error
+}
+
+//Common writes an entry in Common Log Format to l for every request
+//
+//	127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /index.html HTTP/1.1" 200 2326
+func Common(l *logger.Log) func(http.Handler) http.Handler {
+	return adapter(func(req *request) error {
+		_, err := l.Write(append(common(req), '\n'))
+		return err
+	})
+}
+
+//Combined writes an entry in Combined Log Format, Common Log Format followed by the referer and user agent
+func Combined(l *logger.Log) func(http.Handler) http.Handler {
+	return adapter(func(req *request) error {
+		var b = common(req)
+		b = append(b, ' ')
+		b = append(b, quote(req.r.Referer())...)
+		b = append(b, ' ')
+		b = append(b, quote(req.r.UserAgent())...)
+		_, err := l.Write(append(b, '\n'))
+		return err
+	})
+}
+
+//Extended writes a W3C Extended Log File entry with the given field identifiers for every request, DefaultFields if none are given
+//
+//Any request or response header can be logged, such as cs(Accept) or sc(Content-Type).
+//The directives are written at the start of each of l's files, so l should only be used for the access log.
+func Extended(l *logger.Log, identifiers ...string) (func(http.Handler) http.Handler, error) {
+	if len(identifiers) == 0 {
+		identifiers = DefaultFields
+	}
+
+	var builders = make([]func(*request) logfmt.Field, len(identifiers))
+	for i, id := range identifiers {
+		var ok bool
+		if builders[i], ok = builder(id); !ok {
+			return nil, fmt.Errorf("unsupported field identifier %q", id)
+		}
+	}
+
+	ew, err := logfmt.NewELFWriter(l, logfmt.NewDirective("", logfmt.ELFFV, "", identifiers...), logfmt.LF)
+	if err != nil {
+		return nil, err
+	}
+
+	return adapter(func(req *request) error {
+		var e = logfmt.NewEntry()
+		for _, build := range builders {
+			e.Append(build(req))
+		}
+		return ew.Write(e)
+	}), nil
+}
+
+func adapter(write func(*request) error) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return &accessLogHandler{
+			h:     h,
+			write: write,
+		}
+	}
+}
+
+func (h *accessLogHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req = &request{
+		r:     r,
+		w:     NewResponseWriter(w),
+		start: time.Now(),
+	}
+
+	h.h.ServeHTTP(req.w, r)
+	req.taken = time.Since(req.start)
+
+	if err := h.write(req); err != nil {
+		fmt.Fprintf(os.Stderr, "accesslog: could not write entry: %v\n", err)
+	}
+}
+
+//common returns the Common Log Format entry of req without a line terminator
+func common(req *request) []byte {
+	var host, user = logfmt.ClientIP(req.r).String(), logfmt.Username(req.r).String()
+	if host == "" {
+		host = "-"
+	}
+	if user == "" {
+		user = "-"
+	}
+
+	var size = "-"
+	if n := req.w.Size(); n > 0 {
+		size = strconv.Itoa(n)
+	}
+
+	var line = fmt.Sprintf("%s %s %s", req.r.Method, req.r.URL.RequestURI(), req.r.Proto)
+	return []byte(fmt.Sprintf("%s - %s [%s] %s %d %s",
+		host, user, req.start.Format(clfTime), quote(line), req.w.Status(), size))
+}
+
+//quote wraps s in double quotes the way Apache does, escaping quotes and backslashes
+func quote(s string) string {
+	if s == "" {
+		return `"-"`
+	}
+
+	var r = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+	return `"` + r.Replace(s) + `"`
+}
@@ -0,0 +1,206 @@
+package accesslog
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/random9s/cinder/logger"
+	logfmt "github.com/random9s/cinder/logger/format"
+)
+
+var handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/missing" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Write([]byte("hello"))
+})
+
+func serve(t *testing.T, adapter func(http.Handler) http.Handler, target string) {
+	var r = httptest.NewRequest(http.MethodGet, target, nil)
+	r.RemoteAddr = "10.0.0.1:5000"
+	r.SetBasicAuth("frank", "secret")
+	r.Header.Set("User-Agent", `curl/8.0 "test"`)
+	r.Header.Set("Referer", "http://example.com/")
+
+	adapter(handler).ServeHTTP(httptest.NewRecorder(), r)
+}
+
+func readLines(t *testing.T, path string) []string {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Split(strings.TrimSpace(string(b)), "\n")
+}
+
+func TestCommon(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "access.log")
+
+	l, err := logger.New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	serve(t, Common(l), "/index.html?a=1")
+	serve(t, Combined(l), "/missing")
+
+	var lines = readLines(t, path)
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %q", lines)
+	}
+
+	var date = `\[\d{2}/\w{3}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4}\]`
+	var expected = []*regexp.Regexp{
+		regexp.MustCompile(`^10\.0\.0\.1 - frank ` + date + ` "GET /index\.html\?a=1 HTTP/1\.1" 200 5$`),
+		regexp.MustCompile(`^10\.0\.0\.1 - frank ` + date + ` "GET /missing HTTP/1\.1" 404 19 "http://example\.com/" "curl/8\.0 \\"test\\""$`),
+	}
+
+	for i, re := range expected {
+		if !re.MatchString(lines[i]) {
+			t.Errorf("expected %s, got %q", re, lines[i])
+		}
+	}
+}
+
+func TestExtended(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "access.log")
+
+	l, err := logger.New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	if _, err := Extended(l, "cs-method", "cs-cookie"); err == nil {
+		t.Error("expected error for an unsupported field")
+	}
+
+	adapter, err := Extended(l, "c-ip", "cs-username", "cs-method", "cs-uri-stem", "cs-uri-query", "sc-status", "sc-bytes", "cs(User-Agent)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	serve(t, adapter, "/missing")
+	serve(t, adapter, "/index.html?a=1")
+
+	var lines = readLines(t, path)
+	var expected = []string{
+		"#Version: 1.0",
+		"",
+		"#Fields: c-ip cs-username cs-method cs-uri-stem cs-uri-query sc-status sc-bytes cs(User-Agent)",
+		`10.0.0.1 frank GET /missing - 404 19 "curl/8.0 ""test"""`,
+		`10.0.0.1 frank GET /index.html a=1 200 5 "curl/8.0 ""test"""`,
+	}
+
+	if len(lines) != len(expected) {
+		t.Fatalf("expected %d lines, got %q", len(expected), lines)
+	}
+
+	for i, line := range expected {
+		if line != "" && lines[i] != line {
+			t.Errorf("expected %q, got %q", line, lines[i])
+		}
+	}
+
+	if date, err := time.Parse(logfmt.DateTime, strings.TrimPrefix(lines[1], "#Date: ")); err != nil || time.Since(date) > time.Minute {
+		t.Errorf("expected the date the log was started in UTC, got %q", lines[1])
+	}
+}
+
+func TestExtendedHeaders(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "access.log")
+
+	l, err := logger.New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	adapter, err := Extended(l, "cs-version", "cs(Referer)", "sc(X-Content-Type-Options)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	serve(t, adapter, "/missing")
+	serve(t, adapter, "/index.html")
+
+	var lines = readLines(t, path)
+	var expected = []string{
+		"HTTP/1.1 http://example.com/ nosniff",
+		"HTTP/1.1 http://example.com/ -",
+	}
+
+	if len(lines) != 3+len(expected) {
+		t.Fatalf("expected %d lines, got %q", 3+len(expected), lines)
+	}
+
+	for i, line := range expected {
+		if lines[3+i] != line {
+			t.Errorf("expected %q, got %q", line, lines[3+i])
+		}
+	}
+}
+
+func TestCompletionTime(t *testing.T) {
+	var req = &request{start: time.Date(2018, 1, 1, 23, 59, 59, 0, time.UTC), taken: 2 * time.Second}
+
+	var end = time.Date(2018, 1, 2, 0, 0, 1, 0, time.UTC)
+	for _, id := range []string{"date", "time"} {
+		if v := fields[id](req).Value; v != end {
+			t.Errorf("expected %s to be the completion time %v, got %v", id, end, v)
+		}
+	}
+}
+
+func TestHijack(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "access.log")
+
+	l, err := logger.New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	var upgrade = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: test\r\n\r\n")
+		rw.Flush()
+	})
+
+	//The entry is written once the handler returns, after the client has its response
+	var done = make(chan struct{})
+	var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Common(l)(upgrade).ServeHTTP(w, r)
+		close(done)
+	}))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/ws")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	<-done
+
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Errorf("expected 101 from the hijacked connection, got %d", resp.StatusCode)
+	}
+
+	if lines := readLines(t, path); len(lines) != 1 || !strings.Contains(lines[0], `"GET /ws HTTP/1.1" 101 -`) {
+		t.Errorf("unexpected access log %q", lines)
+	}
+}
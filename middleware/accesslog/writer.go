@@ -0,0 +1,77 @@
+package accesslog
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+)
+
+//ResponseWriter records the status and size of a response as it's written
+type ResponseWriter struct {
+	http.ResponseWriter
+	status int
+	size   int
+}
+
+//NewResponseWriter wraps w
+func NewResponseWriter(w http.ResponseWriter) *ResponseWriter {
+	return &ResponseWriter{ResponseWriter: w}
+}
+
+//WriteHeader records the status code before writing it
+func (w *ResponseWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+//Write records the number of bytes written to the body
+func (w *ResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	n, err := w.ResponseWriter.Write(b)
+	w.size += n
+	return n, err
+}
+
+//Flush sends buffered data to the client if the wrapped writer supports it
+func (w *ResponseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		f.Flush()
+	}
+}
+
+//Hijack takes over the connection if the wrapped writer supports it, such as for websocket upgrades
+//
+//The status is recorded as 101 Switching Protocols unless the handler already wrote one.
+func (w *ResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(w.ResponseWriter).Hijack()
+	if err == nil && w.status == 0 {
+		w.status = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
+//Unwrap returns the wrapped writer for http.ResponseController
+func (w *ResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+//Status returns the status code sent, 200 if the handler didn't write anything
+func (w *ResponseWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+//Size returns the number of body bytes written
+func (w *ResponseWriter) Size() int {
+	return w.size
+}
@@ -0,0 +1,99 @@
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/random9s/cinder/logger"
+)
+
+//Header carries the request ID in both the request and the response
+const Header = "X-Request-ID"
+
+//maxLen limits the length of request IDs accepted from clients
+const maxLen = 128
+
+type ctxKey struct{}
+
+type requestIDHandler struct {
+	h   http.Handler
+	log *logger.Log
+}
+
+//Inject reads the request ID from the X-Request-ID header, or generates one, and echoes it in the response
+//
+//A child of l carrying request_id, and trace_id when a W3C traceparent header is sent,
+//is added to the request context and can be retrieved with logger.FromContext.
+func Inject(l *logger.Log) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return &requestIDHandler{
+			h:   h,
+			log: l,
+		}
+	}
+}
+
+func (h *requestIDHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var id = r.Header.Get(Header)
+	if !valid(id) {
+		id = newID()
+	}
+	w.Header().Set(Header, id)
+
+	var kv = []interface{}{"request_id", id}
+	if traceID := traceID(r.Header.Get("traceparent")); traceID != "" {
+		kv = append(kv, "trace_id", traceID)
+	}
+
+	var ctx = context.WithValue(r.Context(), ctxKey{}, id)
+	if h.log != nil {
+		ctx = logger.NewContext(ctx, h.log.With(kv...))
+	}
+
+	h.h.ServeHTTP(w, r.WithContext(ctx))
+}
+
+//FromContext returns the request ID added by Inject, or an empty string if there isn't one
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
+}
+
+//valid reports whether a client supplied ID is safe to log and echo back
+func valid(id string) bool {
+	if id == "" || len(id) > maxLen {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+//newID returns 16 random bytes hex encoded
+func newID() string {
+	var b = make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic("requestid: could not read random bytes: " + err.Error())
+	}
+	return hex.EncodeToString(b)
+}
+
+//traceID returns the trace ID of a traceparent header like 00-<trace id>-<parent id>-<flags>
+func traceID(traceparent string) string {
+	var parts = strings.Split(traceparent, "-")
+	if len(parts) != 4 || len(parts[1]) != 32 || parts[1] == strings.Repeat("0", 32) {
+		return ""
+	}
+
+	if _, err := hex.DecodeString(parts[1]); err != nil {
+		return ""
+	}
+	return parts[1]
+}
@@ -0,0 +1,82 @@
+package requestid
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/random9s/cinder/logger"
+)
+
+func TestInject(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "test.log")
+
+	l, err := logger.New(path, logger.WithEncoder(logger.LogfmtEncoder))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	var handler = Inject(l)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger.FromContext(r.Context()).InfoKV("handled", "id", FromContext(r.Context()))
+	}))
+
+	tests := []struct {
+		description string
+		header      string
+		traceparent string
+		expected    string
+	}{
+		{
+			description: "id sent by client",
+			header:      "abc-123",
+			expected:    "request_id=abc-123",
+		}, {
+			description: "invalid id replaced",
+			header:      "bad id",
+		}, {
+			description: "trace id from traceparent",
+			header:      "abc-456",
+			traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			expected:    "request_id=abc-456 trace_id=4bf92f3577b34da6a3ce929d0e0e4736",
+		},
+	}
+
+	for _, test := range tests {
+		var r = httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set(Header, test.header)
+		if test.traceparent != "" {
+			r.Header.Set("traceparent", test.traceparent)
+		}
+
+		var w = httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		var id = w.Header().Get(Header)
+		if test.expected != "" && id != test.header {
+			t.Errorf("%s: expected response id %q, got %q", test.description, test.header, id)
+		}
+
+		if test.expected == "" && (id == test.header || len(id) != 32) {
+			t.Errorf("%s: expected a generated id, got %q", test.description, id)
+		}
+
+		var expected = test.expected
+		if expected == "" {
+			expected = "request_id=" + id
+		}
+
+		b, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var lines = strings.Split(strings.TrimSpace(string(b)), "\n")
+		if last := lines[len(lines)-1]; !strings.HasSuffix(last, expected+" id="+id) {
+			t.Errorf("%s: unexpected entry %q", test.description, last)
+		}
+	}
+}